@@ -8,10 +8,12 @@ import (
 	"time"
 
 	"folder-system/internal/config"
+	"folder-system/internal/entity"
 	"folder-system/internal/handler"
 	custommiddleware "folder-system/internal/middleware"
 	"folder-system/internal/repository/postgresql"
 	"folder-system/internal/service"
+	"folder-system/internal/storage"
 	"folder-system/pkg/lib"
 
 	"github.com/go-chi/chi/v5"
@@ -72,15 +74,78 @@ func main() {
 		logger.Fatalf("Failed to initialize repository: %v", err)
 	}
 
+	// Initialize blob storage for document files
+	blobStore, err := storage.NewBlobStore(cfg.Storage)
+	if err != nil {
+		logger.Fatalf("Failed to initialize blob storage: %v", err)
+	}
+
 	// Initialize services
-	authService := service.NewAuthService(repo, cfg)
-	documentService := service.NewDocumentService(repo, repo)
-	folderService := service.NewFolderService(repo)
+	authService := service.NewAuthService(repo, repo, repo, cfg)
+	documentService := service.NewDocumentService(repo, repo, repo, repo, repo, blobStore, cfg.Dedup)
+	folderService := service.NewFolderService(repo, repo, repo, repo, repo, repo, cfg.Placement, blobStore)
+	containerService := service.NewContainerService(repo, repo)
+	assignmentService := service.NewAssignmentService(repo)
+	typeService := service.NewTypeService(repo, repo)
+	groupService := service.NewGroupService(repo, repo)
+	auditService := service.NewAuditService(repo)
+	loanService := service.NewLoanService(repo, repo)
+	labelService := service.NewLabelService(repo, repo)
+	scanService := service.NewScanService(repo, repo, repo, repo, folderService, documentService)
+	inventoryService := service.NewInventoryService(repo, repo)
+	disposalService := service.NewDisposalService(repo, repo, repo, blobStore)
+	uploadService, err := service.NewUploadService(repo, repo, repo, documentService, cfg.Storage)
+	if err != nil {
+		logger.Fatalf("Failed to initialize uploads: %v", err)
+	}
 
 	services := &service.Service{
-		Auth:     authService,
-		Document: documentService,
-		Folder:   folderService,
+		Auth:       authService,
+		Document:   documentService,
+		Folder:     folderService,
+		Container:  containerService,
+		Assignment: assignmentService,
+		Type:       typeService,
+		Group:      groupService,
+		Audit:      auditService,
+		Upload:     uploadService,
+		Loan:       loanService,
+		Label:      labelService,
+		Scan:       scanService,
+		Inventory:  inventoryService,
+		Disposal:   disposalService,
+	}
+
+	// Remove abandoned resumable uploads in the background
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			removed, err := uploadService.CleanupExpired()
+			if err != nil {
+				logger.Errorf("Failed to clean up expired uploads: %v", err)
+				continue
+			}
+			if removed > 0 {
+				logger.Infof("Removed %d expired uploads", removed)
+			}
+		}
+	}()
+
+	// Appoint the configured administrator once that account has registered; it
+	// also takes over folders and documents from before ownership was tracked
+	if cfg.Auth.AdminEmail != "" {
+		if admin, err := authService.PromoteAdmin(cfg.Auth.AdminEmail); err != nil {
+			logger.Warnf("Admin %s not promoted: %v", cfg.Auth.AdminEmail, err)
+		} else {
+			logger.Infof("User %s is admin", cfg.Auth.AdminEmail)
+			assigned, err := repo.AssignOrphanedRecords(admin.ID)
+			if err != nil {
+				logger.Errorf("Failed to assign records without an owner: %v", err)
+			} else if assigned > 0 {
+				logger.Infof("Assigned %d records without an owner to %s", assigned, cfg.Auth.AdminEmail)
+			}
+		}
 	}
 
 	// Initialize handlers
@@ -97,24 +162,136 @@ func main() {
 	// ✅ Public routes
 	r.Post("/api/register", handlers.AuthHandler().Register)
 	r.Post("/api/login", handlers.AuthHandler().Login)
+	r.Post("/api/refresh", handlers.AuthHandler().Refresh)
+	r.Post("/api/logout", handlers.AuthHandler().Logout)
 
 	// ✅ Protected routes (под /api/protected — или другой вложенный путь)
 	r.Route("/api/protected", func(r chi.Router) {
 		r.Use(custommiddleware.AuthMiddleware(cfg.JWT.AccessSecret))
 
+		// Role groups: viewers only read, clerks file documents, admins manage types and capacity
+		canFile := custommiddleware.RequireRole(entity.RoleAdmin, entity.RoleClerk)
+		adminOnly := custommiddleware.RequireRole(entity.RoleAdmin)
+
 		// Document routes
 		r.Route("/documents", func(r chi.Router) {
-			r.Post("/", handlers.DocumentHandler().CreateDocument)
+			r.With(canFile).Post("/", handlers.DocumentHandler().CreateDocument)
+			r.Get("/", handlers.DocumentHandler().ListDocuments)
+			r.Get("/search", handlers.DocumentHandler().SearchDocuments)
 			r.Get("/{id}", handlers.DocumentHandler().GetDocument)
-			r.Put("/{id}", handlers.DocumentHandler().UpdateDocument)
-			r.Delete("/{id}", handlers.DocumentHandler().DeleteDocument)
+			r.With(canFile).Put("/{id}", handlers.DocumentHandler().UpdateDocument)
+			r.With(canFile).Delete("/{id}", handlers.DocumentHandler().DeleteDocument)
+			r.Get("/{id}/file", handlers.DocumentHandler().DownloadFile)
+			r.With(canFile).Put("/{id}/file", handlers.DocumentHandler().UploadFile)
+			r.Get("/{id}/label", handlers.LabelHandler().DocumentLabel)
+			r.With(canFile).Put("/{id}/expiry", handlers.DocumentHandler().SetExpiry)
+			r.Get("/{id}/versions", handlers.DocumentHandler().ListVersions)
+			r.With(canFile).Post("/{id}/versions/{n}/restore", handlers.DocumentHandler().RestoreVersion)
+			r.With(canFile).Post("/{id}/uploads", handlers.UploadHandler().CreateUpload)
+		})
+
+		// Resumable uploads of large scans
+		r.Route("/uploads", func(r chi.Router) {
+			r.Use(canFile)
+			r.Head("/{uploadID}", handlers.UploadHandler().GetUploadOffset)
+			r.Patch("/{uploadID}", handlers.UploadHandler().PatchUpload)
+			r.Post("/{uploadID}/finalize", handlers.UploadHandler().FinalizeUpload)
+			r.Delete("/{uploadID}", handlers.UploadHandler().AbortUpload)
 		})
 
 		// Folder routes
 		r.Route("/folders", func(r chi.Router) {
 			r.Get("/recommended", handlers.FolderHandler().GetRecommendedFolder)
+			r.Get("/labels", handlers.LabelHandler().FolderLabelSheet)
+			r.With(canFile).Post("/", handlers.FolderHandler().CreateFolder)
+			r.Get("/", handlers.FolderHandler().ListFolders)
+			r.Get("/{id}", handlers.FolderHandler().GetFolder)
+			r.With(canFile).Patch("/{id}", handlers.FolderHandler().UpdateFolder)
+			r.With(canFile).Delete("/{id}", handlers.FolderHandler().DeleteFolder)
+			r.Get("/{id}/label", handlers.LabelHandler().FolderLabel)
+			r.Get("/{id}/location", handlers.FolderHandler().GetFolderLocation)
+			r.With(canFile).Put("/{id}/location", handlers.FolderHandler().AssignFolderLocation)
+			r.Get("/{id}/location/history", handlers.FolderHandler().ListFolderLocations)
+			r.With(canFile).Post("/{id}/move", handlers.FolderHandler().MoveFolder)
+
+			// Sharing with users and groups
+			r.Get("/{id}/grants", handlers.FolderHandler().ListFolderGrants)
+			r.With(canFile).Post("/{id}/grants", handlers.FolderHandler().ShareFolder)
+			r.With(canFile).Delete("/{id}/grants/{grantID}", handlers.FolderHandler().RevokeFolderGrant)
+		})
+
+		// Physical storage: rooms, cabinets, shelves and boxes folders are kept in
+		r.Route("/containers", func(r chi.Router) {
+			r.Get("/", handlers.ContainerHandler().ListContainers)
+			r.With(adminOnly).Post("/", handlers.ContainerHandler().CreateContainer)
+			r.Get("/{id}", handlers.ContainerHandler().GetContainer)
+			r.With(adminOnly).Patch("/{id}", handlers.ContainerHandler().UpdateContainer)
+			r.With(adminOnly).Delete("/{id}", handlers.ContainerHandler().DeleteContainer)
+			r.Get("/{id}/location", handlers.ContainerHandler().GetContainerLocation)
+			r.With(adminOnly).Post("/{id}/move", handlers.ContainerHandler().MoveContainer)
+		})
+
+		// Lending of paper folders and documents
+		r.Route("/loans", func(r chi.Router) {
+			r.Get("/", handlers.LoanHandler().ListLoans)
+			r.With(canFile).Post("/", handlers.LoanHandler().CheckOut)
+			r.With(canFile).Post("/{id}/checkin", handlers.LoanHandler().CheckIn)
+		})
+
+		// Barcode readers: label lookup and scan sessions that file documents into folders
+		r.Get("/lookup/{code}", handlers.ScanHandler().Lookup)
+		r.Route("/scan-sessions", func(r chi.Router) {
+			r.Use(canFile)
+			r.Post("/", handlers.ScanHandler().StartScanSession)
+			r.Post("/{id}/scans", handlers.ScanHandler().Scan)
+			r.Post("/{id}/close", handlers.ScanHandler().CloseScanSession)
+		})
+
+		// Stock-take: scanned folder contents compared with the database
+		r.Route("/inventory", func(r chi.Router) {
+			r.Use(canFile)
+			r.Post("/", handlers.InventoryHandler().StartInventory)
+			r.Get("/{id}", handlers.InventoryHandler().GetReport)
+			r.Put("/{id}/folders/{folderID}", handlers.InventoryHandler().RecordFolder)
+			r.Post("/{id}/reconcile", handlers.InventoryHandler().Reconcile)
+			r.Post("/{id}/complete", handlers.InventoryHandler().CompleteInventory)
 		})
 
+		// Groups folders can be shared with
+		r.Route("/groups", func(r chi.Router) {
+			r.Get("/", handlers.GroupHandler().ListGroups)
+			r.With(canFile).Post("/", handlers.GroupHandler().CreateGroup)
+			r.With(canFile).Post("/{id}/members", handlers.GroupHandler().AddMember)
+			r.With(canFile).Delete("/{id}/members/{userID}", handlers.GroupHandler().RemoveMember)
+		})
+
+		// Document and folder types
+		r.Get("/document-types", handlers.TypeHandler().ListDocumentTypes)
+		r.With(adminOnly).Post("/document-types", handlers.TypeHandler().CreateDocumentType)
+		r.With(adminOnly).Put("/document-types/{id}/retention", handlers.TypeHandler().SetRetention)
+		r.Get("/folder-types", handlers.TypeHandler().ListFolderTypes)
+		r.With(adminOnly).Post("/folder-types", handlers.TypeHandler().CreateFolderType)
+
+		// Document type -> folder type assignments used by recommendations
+		r.Route("/folder-type-assignments", func(r chi.Router) {
+			r.With(adminOnly).Post("/", handlers.AssignmentHandler().CreateAssignment)
+			r.Get("/", handlers.AssignmentHandler().ListAssignments)
+			r.Get("/{id}", handlers.AssignmentHandler().GetAssignment)
+			r.With(adminOnly).Patch("/{id}", handlers.AssignmentHandler().UpdateAssignment)
+			r.With(adminOnly).Delete("/{id}", handlers.AssignmentHandler().DeleteAssignment)
+		})
+
+		// Retention: documents and folders due for destruction, deleted once approved
+		r.With(adminOnly).Get("/disposal", handlers.DisposalHandler().ListDisposalQueue)
+		r.With(adminOnly).Post("/disposal/approve", handlers.DisposalHandler().ApproveDisposal)
+
+		// Audit trail of document and folder changes
+		r.With(adminOnly).Get("/audit", handlers.AuditHandler().ListAuditRecords)
+		r.With(adminOnly).Get("/audit/verify", handlers.AuditHandler().VerifyAuditChain)
+
+		// User administration
+		r.With(adminOnly).Patch("/users/{id}/role", handlers.AuthHandler().UpdateUserRole)
+
 	})
 
 	// Serve frontend static files
@@ -1,6 +1,45 @@
 const API_BASE = 'http://localhost:8080/api';
 const PROTECTED_API = `${API_BASE}/protected`;
 let accessToken = localStorage.getItem('accessToken');
+let refreshToken = localStorage.getItem('refreshToken');
+
+function setTokens(access, refresh) {
+    accessToken = access;
+    refreshToken = refresh;
+    if (access) {
+        localStorage.setItem('accessToken', access);
+        localStorage.setItem('refreshToken', refresh);
+    } else {
+        localStorage.removeItem('accessToken');
+        localStorage.removeItem('refreshToken');
+    }
+}
+
+// In-flight refresh shared by concurrent 401s: the refresh token rotates on use,
+// so a second refresh with the same token would be treated as reuse and revoke the chain.
+let refreshPromise = null;
+
+function refreshTokens() {
+    if (!refreshPromise) {
+        refreshPromise = doRefreshTokens().finally(() => {
+            refreshPromise = null;
+        });
+    }
+    return refreshPromise;
+}
+
+async function doRefreshTokens() {
+    if (!refreshToken) {
+        return false;
+    }
+    const response = await makeAuthRequest(`${API_BASE}/refresh`, 'POST', { refresh_token: refreshToken });
+    if (!response.ok) {
+        return false;
+    }
+    const data = await response.json();
+    setTokens(data.access_token, data.refresh_token);
+    return true;
+}
 
 function updateAuthStatus() {
     const statusEl = document.getElementById('authStatus');
@@ -35,7 +74,7 @@ async function makeAuthRequest(url, method, body) {
     return response;
 }
 
-async function makeRequest(url, method, body) {
+async function makeRequest(url, method, body, retried) {
     const options = {
         method,
         headers: {
@@ -50,9 +89,11 @@ async function makeRequest(url, method, body) {
     
     const response = await fetch(url, options);
     if (response.status === 401) {
-        // Token expired
-        accessToken = null;
-        localStorage.removeItem('accessToken');
+        // Access token expired, try to rotate the refresh token once
+        if (!retried && await refreshTokens()) {
+            return makeRequest(url, method, body, true);
+        }
+        setTokens(null, null);
         updateAuthStatus();
         throw new Error('Unauthorized');
     }
@@ -87,8 +128,7 @@ async function login() {
         const response = await makeAuthRequest(`${API_BASE}/login`, 'POST', { email, password });
         if (response.ok) {
             const data = await response.json();
-            accessToken = data.access_token;
-            localStorage.setItem('accessToken', accessToken);
+            setTokens(data.access_token, data.refresh_token);
             updateAuthStatus();
         } else {
             const error = await response.json();
@@ -99,9 +139,15 @@ async function login() {
     }
 }
 
-function logout() {
-    accessToken = null;
-    localStorage.removeItem('accessToken');
+async function logout() {
+    if (refreshToken) {
+        try {
+            await makeAuthRequest(`${API_BASE}/logout`, 'POST', { refresh_token: refreshToken });
+        } catch (error) {
+            // Forget the tokens locally even if the server is unreachable
+        }
+    }
+    setTokens(null, null);
     updateAuthStatus();
 }
 
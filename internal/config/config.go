@@ -4,15 +4,20 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Logging  LoggingConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	Auth      AuthConfig
+	Logging   LoggingConfig
+	Placement PlacementConfig
+	Storage   StorageConfig
+	Dedup     DeduplicationConfig
 }
 
 type ServerConfig struct {
@@ -36,11 +41,60 @@ type JWTConfig struct {
 	RefreshTTL    int
 }
 
+// AuthConfig controls who administers the system. AdminEmail names an already
+// registered account that is promoted to admin on startup; self-registration
+// never grants more than the clerk role.
+type AuthConfig struct {
+	AdminEmail string
+}
+
 type LoggingConfig struct {
 	Level string
 	File  string
 }
 
+// PlacementConfig selects the folder placement strategy used for recommendations.
+// ByFolderType overrides DefaultStrategy for individual folder types.
+type PlacementConfig struct {
+	DefaultStrategy string
+	ByFolderType    map[uint]string
+}
+
+// DeduplicationConfig decides what happens when a document gets a file identical
+// to another document's: "allow" it silently, "warn" by reporting duplicate_of, or
+// "block" it. ByDocumentType overrides DefaultPolicy for individual document types.
+type DeduplicationConfig struct {
+	DefaultPolicy  string
+	ByDocumentType map[uint]string
+}
+
+// StorageConfig selects where uploaded document files are kept: "local" stores
+// them below LocalDir, "s3" in an S3-compatible bucket.
+type StorageConfig struct {
+	Backend  string
+	LocalDir string
+	S3       S3Config
+	// UploadDir holds partial files of resumable uploads until they complete.
+	UploadDir string
+	// UploadTTL is how many hours an unfinished resumable upload is kept.
+	UploadTTL int
+}
+
+// S3Config describes an S3-compatible bucket. PathStyle addresses the bucket as
+// endpoint/bucket/key, which is what MinIO and most self-hosted stores expect.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+	// Timeout is how many seconds to wait for a connection, for the response to
+	// start and for each read or write while a file is transferred. A transfer that
+	// keeps making progress is never cut off, however long the file.
+	Timeout int
+}
+
 func LoadConfig() (*Config, error) {
 	// Загружаем .env файл
 	if err := godotenv.Load(); err != nil {
@@ -66,10 +120,36 @@ func LoadConfig() (*Config, error) {
 			AccessTTL:     getEnvAsInt("JWT_ACCESS_TTL", 15),
 			RefreshTTL:    getEnvAsInt("JWT_REFRESH_TTL", 10080),
 		},
+		Auth: AuthConfig{
+			AdminEmail: getEnv("ADMIN_EMAIL", ""),
+		},
 		Logging: LoggingConfig{
 			Level: getEnv("LOG_LEVEL", "debug"),
 			File:  getEnv("LOG_FILE", "app.log"),
 		},
+		Placement: PlacementConfig{
+			DefaultStrategy: getEnv("PLACEMENT_STRATEGY", "first-fit"),
+			ByFolderType:    getEnvAsUintMap("PLACEMENT_FOLDER_TYPE_STRATEGIES"),
+		},
+		Dedup: DeduplicationConfig{
+			DefaultPolicy:  getEnv("DUPLICATE_POLICY", "warn"),
+			ByDocumentType: getEnvAsUintMap("DUPLICATE_DOCUMENT_TYPE_POLICIES"),
+		},
+		Storage: StorageConfig{
+			Backend:  getEnv("STORAGE_BACKEND", "local"),
+			LocalDir: getEnv("STORAGE_LOCAL_DIR", "data/blobs"),
+			S3: S3Config{
+				Endpoint:  getEnv("S3_ENDPOINT", "http://localhost:9000"),
+				Region:    getEnv("S3_REGION", "us-east-1"),
+				Bucket:    getEnv("S3_BUCKET", "folder-system"),
+				AccessKey: getEnv("S3_ACCESS_KEY", ""),
+				SecretKey: getEnv("S3_SECRET_KEY", ""),
+				PathStyle: getEnvAsBool("S3_PATH_STYLE", true),
+				Timeout:   getEnvAsInt("S3_TIMEOUT", 60),
+			},
+			UploadDir: getEnv("UPLOAD_DIR", "data/uploads"),
+			UploadTTL: getEnvAsInt("UPLOAD_TTL", 24),
+		},
 	}, nil
 }
 
@@ -89,3 +169,30 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsUintMap parses a value like "2:best-fit,3:worst-fit" into an ID -> string map.
+// Malformed entries are skipped.
+func getEnvAsUintMap(key string) map[uint]string {
+	result := make(map[uint]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		idStr, value, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSpace(idStr), 10, 32)
+		if err != nil {
+			continue
+		}
+		result[uint(id)] = strings.TrimSpace(value)
+	}
+	return result
+}
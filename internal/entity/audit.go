@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Audited entity types.
+const (
+	AuditEntityDocument       = "document"
+	AuditEntityFolder         = "folder"
+	AuditEntityFolderGrant    = "folder_grant"
+	AuditEntityContainer      = "container"
+	AuditEntityFolderLocation = "folder_location"
+	AuditEntityLoan           = "loan"
+	AuditEntityDocumentType   = "document_type"
+)
+
+// Audited actions.
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+	// AuditActionDispose is a deletion approved from the retention disposal queue.
+	AuditActionDispose = "dispose"
+)
+
+// AuditRecord is one entry of the append-only audit trail. Before and After are
+// snapshots of the entity, Changes lists only the fields that differ.
+// Records form a hash chain: Hash covers the record's content and PrevHash, the
+// Hash of the record before it, so editing any record breaks every later link.
+type AuditRecord struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `gorm:"index;not null" json:"created_at"`
+	ActorID    uint      `gorm:"index;not null" json:"actor_id"`
+	Action     string    `gorm:"not null" json:"action"`
+	EntityType string    `gorm:"not null;index:idx_audit_entity" json:"entity_type"`
+	EntityID   uint      `gorm:"not null;index:idx_audit_entity" json:"entity_id"`
+	Before     JSON      `gorm:"type:jsonb" json:"before"`
+	After      JSON      `gorm:"type:jsonb" json:"after"`
+	Changes    JSON      `gorm:"type:jsonb" json:"changes"`
+	PrevHash   string    `gorm:"not null;default:''" json:"prev_hash"`
+	Hash       string    `gorm:"not null;default:''" json:"hash"`
+}
+
+// ComputeHash returns the SHA-256 chain hash of the record. JSON fields are
+// canonicalized first because jsonb does not preserve key order or formatting,
+// and CreatedAt is taken in UTC at the microsecond precision PostgreSQL stores.
+func (r *AuditRecord) ComputeHash() (string, error) {
+	parts := []string{
+		r.PrevHash,
+		r.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+		strconv.FormatUint(uint64(r.ActorID), 10),
+		r.Action,
+		r.EntityType,
+		strconv.FormatUint(uint64(r.EntityID), 10),
+	}
+	for _, field := range []JSON{r.Before, r.After, r.Changes} {
+		canonical, err := canonicalJSON(field)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, canonical)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x1f")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func canonicalJSON(j JSON) (string, error) {
+	if len(j) == 0 {
+		return "", nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(j, &v); err != nil {
+		return "", err
+	}
+	// encoding/json writes map keys in sorted order
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// FieldChange is the old and new value of a single field in an audit diff.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// Blob is a stored file content shared by every document with the same SHA-256.
+// RefCount is the number of documents pointing to it; the content is removed
+// from the blob store when it drops to zero.
+type Blob struct {
+	Hash      string    `gorm:"primarykey" json:"hash"` // hex SHA-256 of the content
+	CreatedAt time.Time `json:"created_at"`
+	Key       string    `gorm:"not null" json:"-"` // key of the content in the blob store
+	Size      int64     `gorm:"not null" json:"size"`
+	MIMEType  string    `json:"mime_type"`
+	RefCount  int       `gorm:"not null;default:0" json:"ref_count"`
+}
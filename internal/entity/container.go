@@ -0,0 +1,42 @@
+package entity
+
+import "gorm.io/gorm"
+
+// Container kinds, from the outermost to the innermost. A container can only be
+// placed inside a container of an outer kind; folders fit into any container.
+const (
+	ContainerRoom    = "room"
+	ContainerCabinet = "cabinet"
+	ContainerShelf   = "shelf"
+	ContainerBox     = "box"
+)
+
+var containerKindLevels = map[string]int{
+	ContainerRoom:    0,
+	ContainerCabinet: 1,
+	ContainerShelf:   2,
+	ContainerBox:     3,
+}
+
+// IsValidContainerKind reports whether kind is one of the known container kinds.
+func IsValidContainerKind(kind string) bool {
+	_, ok := containerKindLevels[kind]
+	return ok
+}
+
+// CanContain reports whether a container of kind parent can hold one of kind child.
+func CanContain(parent, child string) bool {
+	return containerKindLevels[parent] < containerKindLevels[child]
+}
+
+// Container is a physical storage place for folders: a room, a cabinet or shelf in
+// it, or an archive box on a shelf. Containers nest through ParentID.
+type Container struct {
+	gorm.Model
+	Name     string `gorm:"not null" json:"name"`
+	Kind     string `gorm:"not null;index" json:"kind"`
+	ParentID *uint  `gorm:"index" json:"parent_id"` // nil for top-level containers
+	// Capacity is how many direct children (containers and folders) fit; 0 means unlimited.
+	Capacity  int  `gorm:"not null;default:0" json:"capacity"`
+	CreatedBy uint `gorm:"index" json:"created_by"`
+}
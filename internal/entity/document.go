@@ -1,6 +1,10 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Document struct {
 	gorm.Model
@@ -10,4 +14,19 @@ type Document struct {
 	Folder         *Folder      `gorm:"foreignKey:FolderID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"folder,omitempty"`
 	DocumentTypeID uint         `json:"document_type_id"`
 	DocumentType   DocumentType `json:"document_type"`
+	CreatedBy      uint         `gorm:"index" json:"created_by"`
+	FileName       string       `json:"file_name,omitempty"`
+	FileSize       int64        `json:"file_size,omitempty"`
+	FileHash       string       `gorm:"index" json:"file_hash,omitempty"` // hex SHA-256 of the content
+	FileMIMEType   string       `json:"file_mime_type,omitempty"`
+	FileKey        string       `json:"-"` // key of the content in the blob store
+	// ExpiresAt is when the document stops being in force, e.g. the end of a contract.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// DisposalDate is when the retention period of the document ends; nil while it
+	// is kept indefinitely. It is computed by the database and never written.
+	DisposalDate *time.Time `gorm:"->;-:migration" json:"disposal_date,omitempty"`
+	// DuplicateOf lists other documents with the same content; filled in on create and upload only.
+	DuplicateOf []uint `gorm:"-" json:"duplicate_of,omitempty"`
+	// Location tells where the paper document's folder is kept; filled in by GET only.
+	Location *FolderLocation `gorm:"-" json:"location,omitempty"`
 }
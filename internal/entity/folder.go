@@ -4,9 +4,11 @@ import "gorm.io/gorm"
 
 type Folder struct {
 	gorm.Model
-	Name         string `gorm:"not null"`
-	TotalSheets  int    `gorm:"not null;default:480"`
-	UsedSheets   int    `gorm:"not null;default:0"`
-	FolderTypeID uint
-	FolderType   FolderType
+	Name         string     `gorm:"not null" json:"name"`
+	TotalSheets  int        `gorm:"not null;default:480" json:"total_sheets"`
+	UsedSheets   int        `gorm:"not null;default:0" json:"used_sheets"`
+	FolderTypeID uint       `json:"folder_type_id"`
+	FolderType   FolderType `json:"folder_type"`
+	ContainerID  *uint      `gorm:"index" json:"container_id"` // box or shelf the folder is kept in
+	CreatedBy    uint       `gorm:"index" json:"created_by"`
 }
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// InventorySession is a stock-take: the shelves are walked and the documents
+// found in each folder are scanned, then compared with what the database says.
+type InventorySession struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CreatedBy   uint       `gorm:"not null;index" json:"created_by"`
+	Note        string     `json:"note,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// InventoryFolder marks a folder whose contents were recorded in a session, so
+// that a folder found empty is told apart from one nobody looked at.
+type InventoryFolder struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	SessionID  uint      `gorm:"not null;uniqueIndex:idx_inventory_folder" json:"session_id"`
+	FolderID   uint      `gorm:"not null;uniqueIndex:idx_inventory_folder" json:"folder_id"`
+	RecordedBy uint      `gorm:"not null" json:"recorded_by"`
+	RecordedAt time.Time `gorm:"not null" json:"recorded_at"`
+}
+
+// InventoryItem is one code scanned in a folder during a session. DocumentID is
+// nil when the code does not name a document.
+type InventoryItem struct {
+	ID         uint   `gorm:"primarykey" json:"id"`
+	SessionID  uint   `gorm:"not null;index" json:"session_id"`
+	FolderID   uint   `gorm:"not null" json:"folder_id"`
+	Code       string `gorm:"not null" json:"code"`
+	DocumentID *uint  `json:"document_id,omitempty"`
+}
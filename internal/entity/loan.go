@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// Loan is a physical folder or a single paper document checked out to a user.
+// Exactly one of FolderID and DocumentID is set. A loan is active until it is
+// returned; an item can only have one active loan at a time.
+type Loan struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FolderID   *uint      `gorm:"uniqueIndex:idx_active_folder_loan,where:returned_at IS NULL" json:"folder_id,omitempty"`
+	DocumentID *uint      `gorm:"uniqueIndex:idx_active_document_loan,where:returned_at IS NULL" json:"document_id,omitempty"`
+	BorrowerID uint       `gorm:"not null;index" json:"borrower_id"`
+	LentBy     uint       `gorm:"not null;index" json:"lent_by"`
+	DueAt      time.Time  `gorm:"not null;index" json:"due_at"`
+	ReturnedAt *time.Time `json:"returned_at,omitempty"`
+	ReturnedTo *uint      `json:"returned_to,omitempty"` // user who took the item back
+	Note       string     `json:"note,omitempty"`
+	Overdue    bool       `gorm:"-" json:"overdue"`
+}
+
+// IsOverdue reports whether the loan is still active past its due time.
+func (l *Loan) IsOverdue(now time.Time) bool {
+	return l.ReturnedAt == nil && now.After(l.DueAt)
+}
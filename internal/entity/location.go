@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// FolderLocation is an entry of a folder's location history: where the folder was
+// put, by whom and when. The newest entry is the folder's current location.
+// The address is recorded as it was at that moment; ContainerID links it to the
+// storage hierarchy.
+type FolderLocation struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+	FolderID    uint      `gorm:"not null;index" json:"folder_id"`
+	ContainerID *uint     `json:"container_id,omitempty"`
+	Site        string    `json:"site,omitempty"`
+	Room        string    `json:"room,omitempty"`
+	Rack        string    `json:"rack,omitempty"`
+	Shelf       string    `json:"shelf,omitempty"`
+	Position    string    `json:"position,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	MovedBy     uint      `gorm:"index" json:"moved_by"`
+	// Path is the current container path, e.g. "Archive / Rack 2 / Box 7"; not stored.
+	Path string `gorm:"-" json:"path,omitempty"`
+}
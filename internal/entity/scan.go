@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// ScanSession is a run of barcode scans at the shelf. Scanning a folder label
+// makes it the current folder; documents scanned after it are filed into it.
+type ScanSession struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	CreatedBy  uint       `gorm:"not null;index" json:"created_by"`
+	FolderID   *uint      `json:"folder_id,omitempty"` // current folder, nil until one is scanned
+	FiledCount int        `gorm:"not null;default:0" json:"filed_count"`
+	ClosedAt   *time.Time `json:"closed_at,omitempty"`
+}
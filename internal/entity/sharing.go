@@ -0,0 +1,57 @@
+package entity
+
+import "gorm.io/gorm"
+
+// Folder grant permissions. Each one includes the ones before it:
+// manage implies file, file implies read.
+const (
+	PermissionRead   = "read"
+	PermissionFile   = "file"
+	PermissionManage = "manage"
+)
+
+var permissionLevels = []string{PermissionRead, PermissionFile, PermissionManage}
+
+// IsValidPermission reports whether permission is one of the known grant permissions.
+func IsValidPermission(permission string) bool {
+	for _, p := range permissionLevels {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionsAtLeast returns permission and every permission that implies it.
+func PermissionsAtLeast(permission string) []string {
+	for i, p := range permissionLevels {
+		if p == permission {
+			return permissionLevels[i:]
+		}
+	}
+	return nil
+}
+
+// Group is a team of users a folder can be shared with.
+type Group struct {
+	gorm.Model
+	Name      string        `gorm:"not null" json:"name"`
+	CreatedBy uint          `gorm:"index" json:"created_by"`
+	Members   []GroupMember `json:"members,omitempty"`
+}
+
+type GroupMember struct {
+	gorm.Model
+	GroupID uint `gorm:"not null;uniqueIndex:idx_group_member" json:"group_id"`
+	UserID  uint `gorm:"not null;uniqueIndex:idx_group_member" json:"user_id"`
+}
+
+// FolderGrant shares a folder with either a single user or a group. A grantee
+// holds each permission on a folder at most once.
+type FolderGrant struct {
+	gorm.Model
+	FolderID   uint   `gorm:"not null;index;uniqueIndex:idx_folder_user_grant,where:deleted_at IS NULL;uniqueIndex:idx_folder_group_grant,where:deleted_at IS NULL" json:"folder_id"`
+	UserID     *uint  `gorm:"index;uniqueIndex:idx_folder_user_grant,where:deleted_at IS NULL" json:"user_id,omitempty"`
+	GroupID    *uint  `gorm:"index;uniqueIndex:idx_folder_group_grant,where:deleted_at IS NULL" json:"group_id,omitempty"`
+	Permission string `gorm:"not null;uniqueIndex:idx_folder_user_grant,where:deleted_at IS NULL;uniqueIndex:idx_folder_group_grant,where:deleted_at IS NULL" json:"permission"`
+}
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken records an issued refresh token. Tokens issued from one login form a
+// family linked through ParentJTI; presenting a token that was already rotated or
+// revoked revokes the whole family.
+type RefreshToken struct {
+	gorm.Model
+	JTI       string `gorm:"uniqueIndex;not null"`
+	UserID    uint   `gorm:"index;not null"`
+	FamilyID  string `gorm:"index;not null"`
+	ParentJTI *string
+	ExpiresAt time.Time `gorm:"not null"`
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
@@ -2,18 +2,39 @@ package entity
 
 import "gorm.io/gorm"
 
+// Events a retention period can start from.
+const (
+	// RetentionTriggerCreated counts from the day the document was filed.
+	RetentionTriggerCreated = "created"
+	// RetentionTriggerExpiry counts from the document's expiry date, e.g. the end
+	// of a contract. Documents without one are kept.
+	RetentionTriggerExpiry = "expiry"
+)
+
+// IsValidRetentionTrigger reports whether trigger is a known retention trigger.
+func IsValidRetentionTrigger(trigger string) bool {
+	return trigger == RetentionTriggerCreated || trigger == RetentionTriggerExpiry
+}
+
+// DocumentType carries the retention rule of its documents: they may be destroyed
+// RetentionMonths after RetentionTrigger. A RetentionMonths of 0 keeps them forever.
 type DocumentType struct {
 	gorm.Model
-	Name string `gorm:"not null"`
+	Name             string `gorm:"not null" json:"name"`
+	RetentionMonths  int    `gorm:"not null;default:0" json:"retention_months"`
+	RetentionTrigger string `gorm:"not null;default:created" json:"retention_trigger"`
 }
 
 type FolderType struct {
 	gorm.Model
-	Name string `gorm:"not null"`
+	Name string `gorm:"not null" json:"name"`
 }
 
+// FolderTypeAssignment marks a folder type as compatible with a document type.
+// When several folder types fit, the one with the lowest Priority is tried first.
 type FolderTypeAssignment struct {
 	gorm.Model
-	DocumentTypeID uint `gorm:"not null"`
-	FolderTypeID   uint `gorm:"not null"`
+	DocumentTypeID uint `gorm:"not null;uniqueIndex:idx_assignment_types" json:"document_type_id"`
+	FolderTypeID   uint `gorm:"not null;uniqueIndex:idx_assignment_types" json:"folder_type_id"`
+	Priority       int  `gorm:"not null;default:0" json:"priority"`
 }
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// Upload is a resumable upload in progress. Chunks are appended to a partial file
+// until Offset reaches Length, then the content is attached to the document.
+type Upload struct {
+	ID          string     `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DocumentID  uint       `gorm:"not null;index" json:"document_id"`
+	CreatedBy   uint       `gorm:"not null;index" json:"created_by"`
+	Length      int64      `gorm:"not null" json:"length"`
+	Offset      int64      `gorm:"not null;default:0" json:"offset"`
+	FileName    string     `json:"file_name"`
+	MIMEType    string     `json:"mime_type"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
@@ -2,8 +2,26 @@ package entity
 
 import "gorm.io/gorm"
 
+// Roles a user can have. Admins manage types and folder capacity, clerks file
+// documents, viewers can only read.
+const (
+	RoleAdmin  = "admin"
+	RoleClerk  = "clerk"
+	RoleViewer = "viewer"
+)
+
 type User struct {
 	gorm.Model
 	Email    string `gorm:"uniqueIndex;not null"`
 	Password string `gorm:"not null"`
+	Role     string `gorm:"not null;default:clerk"`
+}
+
+// IsValidRole reports whether role is one of the known roles.
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleClerk, RoleViewer:
+		return true
+	}
+	return false
 }
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// DocumentVersion is an immutable snapshot of a document taken after each change.
+// Version numbers start at 1 per document.
+type DocumentVersion struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	DocumentID     uint      `gorm:"not null;uniqueIndex:idx_document_version" json:"document_id"`
+	Version        int       `gorm:"not null;uniqueIndex:idx_document_version" json:"version"`
+	Title          string    `gorm:"not null" json:"title"`
+	SheetsCount    int       `gorm:"not null" json:"sheets_count"`
+	FolderID       *uint     `json:"folder_id"`
+	DocumentTypeID uint      `json:"document_type_id"`
+	ChangedBy      uint      `gorm:"index" json:"changed_by"`
+	ChangedFields  JSON      `gorm:"type:jsonb" json:"changed_fields"`
+	RestoredFrom   *int      `json:"restored_from,omitempty"`
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"folder-system/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AssignmentHandler struct {
+	assignmentService service.AssignmentService
+}
+
+func NewAssignmentHandler(assignmentService service.AssignmentService) *AssignmentHandler {
+	return &AssignmentHandler{assignmentService: assignmentService}
+}
+
+type CreateAssignmentRequest struct {
+	DocumentTypeID uint `json:"document_type_id"`
+	FolderTypeID   uint `json:"folder_type_id"`
+	Priority       int  `json:"priority"`
+}
+
+type UpdateAssignmentRequest struct {
+	FolderTypeID *uint `json:"folder_type_id,omitempty"`
+	Priority     *int  `json:"priority,omitempty"`
+}
+
+func (h *AssignmentHandler) CreateAssignment(w http.ResponseWriter, r *http.Request) {
+	var req CreateAssignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.DocumentTypeID == 0 || req.FolderTypeID == 0 {
+		WriteJSONError(w, http.StatusBadRequest, "document_type_id and folder_type_id are required")
+		return
+	}
+
+	assignment, err := h.assignmentService.CreateAssignment(req.DocumentTypeID, req.FolderTypeID, req.Priority)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(assignment)
+}
+
+// ListAssignments returns all assignments, or only those of one document type
+// when the document_type_id query parameter is set.
+func (h *AssignmentHandler) ListAssignments(w http.ResponseWriter, r *http.Request) {
+	documentTypeID, err := parseOptionalUint(r.URL.Query().Get("document_type_id"))
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid document_type_id")
+		return
+	}
+
+	assignments, err := h.assignmentService.ListAssignments(documentTypeID)
+	if err != nil {
+		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(assignments)
+}
+
+func (h *AssignmentHandler) GetAssignment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid assignment ID")
+		return
+	}
+
+	assignment, err := h.assignmentService.GetAssignment(uint(id))
+	if err != nil {
+		WriteJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(assignment)
+}
+
+func (h *AssignmentHandler) UpdateAssignment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid assignment ID")
+		return
+	}
+
+	var req UpdateAssignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	assignment, err := h.assignmentService.UpdateAssignment(uint(id), req.FolderTypeID, req.Priority)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrAssignmentNotFound) {
+			status = http.StatusNotFound
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(assignment)
+}
+
+func (h *AssignmentHandler) DeleteAssignment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid assignment ID")
+		return
+	}
+
+	err = h.assignmentService.DeleteAssignment(uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrAssignmentNotFound) {
+			status = http.StatusNotFound
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"folder-system/internal/service"
+)
+
+// defaultAuditLimit caps the number of audit records returned when no limit is given.
+const defaultAuditLimit = 100
+
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListAuditRecords returns audit records, newest first. Supported query parameters:
+// entity_type, entity_id, user_id, from and to (RFC 3339) and limit.
+func (h *AuditHandler) ListAuditRecords(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := service.AuditFilter{
+		EntityType: query.Get("entity_type"),
+		Limit:      defaultAuditLimit,
+	}
+
+	var err error
+	if filter.EntityID, err = parseOptionalUint(query.Get("entity_id")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid entity_id")
+		return
+	}
+	if filter.ActorID, err = parseOptionalUint(query.Get("user_id")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid user_id")
+		return
+	}
+	if filter.From, err = parseOptionalTime(query.Get("from")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid from, expected RFC 3339 time")
+		return
+	}
+	if filter.To, err = parseOptionalTime(query.Get("to")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid to, expected RFC 3339 time")
+		return
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	records, err := h.auditService.ListAuditRecords(filter)
+	if err != nil {
+		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(records)
+}
+
+// VerifyAuditChain walks the audit hash chain and reports the first broken link.
+// The returned head_hash can be kept outside the database to also detect
+// truncation of the newest records.
+func (h *AuditHandler) VerifyAuditChain(w http.ResponseWriter, r *http.Request) {
+	result, err := h.auditService.VerifyAuditChain()
+	if err != nil {
+		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"folder-system/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ContainerHandler struct {
+	containerService service.ContainerService
+}
+
+func NewContainerHandler(containerService service.ContainerService) *ContainerHandler {
+	return &ContainerHandler{containerService: containerService}
+}
+
+type CreateContainerRequest struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	ParentID *uint  `json:"parent_id,omitempty"`
+	Capacity int    `json:"capacity"`
+}
+
+// UpdateContainerRequest changes only the fields that are present.
+type UpdateContainerRequest struct {
+	Name     *string `json:"name,omitempty"`
+	Capacity *int    `json:"capacity,omitempty"`
+}
+
+// MoveContainerRequest moves a container with its contents; a parent_id of 0 or
+// null makes it a top-level container.
+type MoveContainerRequest struct {
+	ParentID *uint `json:"parent_id"`
+}
+
+func (h *ContainerHandler) CreateContainer(w http.ResponseWriter, r *http.Request) {
+	var req CreateContainerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	container, err := h.containerService.CreateContainer(actorFromRequest(r), req.Name, req.Kind, req.ParentID, req.Capacity)
+	if err != nil {
+		writeContainerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(container)
+}
+
+func (h *ContainerHandler) ListContainers(w http.ResponseWriter, r *http.Request) {
+	containers, err := h.containerService.ListContainers()
+	if err != nil {
+		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(containers)
+}
+
+func (h *ContainerHandler) GetContainer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid container ID")
+		return
+	}
+
+	container, err := h.containerService.GetContainer(uint(id))
+	if err != nil {
+		writeContainerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(container)
+}
+
+func (h *ContainerHandler) UpdateContainer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid container ID")
+		return
+	}
+
+	var req UpdateContainerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	container, err := h.containerService.UpdateContainer(actorFromRequest(r), uint(id), req.Name, req.Capacity)
+	if err != nil {
+		writeContainerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(container)
+}
+
+func (h *ContainerHandler) DeleteContainer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid container ID")
+		return
+	}
+
+	if err := h.containerService.DeleteContainer(actorFromRequest(r), uint(id)); err != nil {
+		writeContainerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ContainerHandler) MoveContainer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid container ID")
+		return
+	}
+
+	var req MoveContainerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	container, err := h.containerService.MoveContainer(actorFromRequest(r), uint(id), req.ParentID)
+	if err != nil {
+		writeContainerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(container)
+}
+
+// GetContainerLocation returns the container and the containers it is nested in,
+// outermost first.
+func (h *ContainerHandler) GetContainerLocation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid container ID")
+		return
+	}
+
+	location, err := h.containerService.GetContainerLocation(uint(id))
+	if err != nil {
+		writeContainerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(location)
+}
+
+func writeContainerError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrContainerNotFound), errors.Is(err, service.ErrFolderNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrContainerNotEmpty), errors.Is(err, service.ErrContainerFull),
+		errors.Is(err, service.ErrContainerCycle), errors.Is(err, service.ErrCapacityBelowContent):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrInvalidContainer), errors.Is(err, service.ErrInvalidNesting):
+		status = http.StatusBadRequest
+	}
+	WriteJSONError(w, status, err.Error())
+}
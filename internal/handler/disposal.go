@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"folder-system/internal/service"
+)
+
+type DisposalHandler struct {
+	disposalService service.DisposalService
+}
+
+func NewDisposalHandler(disposalService service.DisposalService) *DisposalHandler {
+	return &DisposalHandler{disposalService: disposalService}
+}
+
+// ApproveDisposalRequest lists the documents and folders approved for destruction.
+type ApproveDisposalRequest struct {
+	DocumentIDs []uint `json:"document_ids"`
+	FolderIDs   []uint `json:"folder_ids"`
+}
+
+// ListDisposalQueue lists what may be destroyed now, or by the time given in at
+// (RFC 3339) to plan ahead.
+func (h *DisposalHandler) ListDisposalQueue(w http.ResponseWriter, r *http.Request) {
+	at, err := parseOptionalTime(r.URL.Query().Get("at"))
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid at, expected RFC 3339")
+		return
+	}
+	if at == nil {
+		now := time.Now()
+		at = &now
+	}
+
+	queue, err := h.disposalService.ListDisposalQueue(actorFromRequest(r), *at)
+	if err != nil {
+		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(queue)
+}
+
+func (h *DisposalHandler) ApproveDisposal(w http.ResponseWriter, r *http.Request) {
+	var req ApproveDisposalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.DocumentIDs) == 0 && len(req.FolderIDs) == 0 {
+		WriteJSONError(w, http.StatusBadRequest, "document_ids or folder_ids is required")
+		return
+	}
+
+	result, err := h.disposalService.ApproveDisposal(actorFromRequest(r), req.DocumentIDs, req.FolderIDs)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrDocumentNotFound), errors.Is(err, service.ErrFolderNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrNotDisposable):
+			status = http.StatusConflict
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
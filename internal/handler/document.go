@@ -1,15 +1,35 @@
 package handler
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"folder-system/internal/service"
 
 	"github.com/go-chi/chi/v5"
 )
 
+const (
+	// maxUploadSize limits the size of a single file upload.
+	maxUploadSize = 1 << 30
+	// multipartMemory is how much of a multipart upload is kept in memory before
+	// spilling to a temporary file.
+	multipartMemory = 32 << 20
+	// defaultSearchLimit and maxSearchLimit bound the number of search hits returned.
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// defaultPageSize and maxPageSize bound the number of documents on a list page.
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
 type DocumentHandler struct {
 	documentService service.DocumentService
 }
@@ -23,14 +43,24 @@ type CreateDocumentRequest struct {
 	SheetsCount    int    `json:"sheets_count"`
 	FolderID       *uint  `json:"folder_id"`
 	DocumentTypeID uint   `json:"document_type_id"`
+	// FileHash is the optional hex SHA-256 of the scan about to be uploaded,
+	// used to report duplicates before the upload.
+	FileHash string `json:"file_hash,omitempty"`
 }
 
+// UpdateDocumentRequest changes only the fields that are present.
+// A folder_id of 0 takes the document out of its folder.
 type UpdateDocumentRequest struct {
 	Title       *string `json:"title,omitempty"`
 	SheetsCount *int    `json:"sheets_count,omitempty"`
 	FolderID    *uint   `json:"folder_id,omitempty"`
 }
 
+// SetExpiryRequest sets or, with a null expires_at, clears the expiry date.
+type SetExpiryRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
 func (h *DocumentHandler) CreateDocument(w http.ResponseWriter, r *http.Request) {
 	var req CreateDocumentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -47,8 +77,29 @@ func (h *DocumentHandler) CreateDocument(w http.ResponseWriter, r *http.Request)
 		req.DocumentTypeID = 1 // default document type id
 	}
 
-	document, err := h.documentService.CreateDocument(req.Title, req.SheetsCount, req.FolderID, req.DocumentTypeID)
+	req.FileHash = strings.ToLower(req.FileHash)
+	if req.FileHash != "" && !isSHA256Hex(req.FileHash) {
+		WriteJSONError(w, http.StatusBadRequest, "file_hash must be a hex SHA-256")
+		return
+	}
+
+	override, err := overrideCheckout(r)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid override_checkout")
+		return
+	}
+
+	document, err := h.documentService.CreateDocument(actorFromRequest(r), req.Title, req.SheetsCount, req.FolderID, req.DocumentTypeID, req.FileHash, override)
 	if err != nil {
+		var duplicateErr *service.DuplicateFileError
+		if errors.As(err, &duplicateErr) {
+			writeDuplicateFileError(w, duplicateErr)
+			return
+		}
+		if errors.Is(err, service.ErrFolderCheckedOut) {
+			WriteJSONError(w, http.StatusConflict, err.Error())
+			return
+		}
 		WriteJSONError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -66,7 +117,7 @@ func (h *DocumentHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	document, err := h.documentService.GetDocument(uint(id))
+	document, err := h.documentService.GetDocument(actorFromRequest(r), uint(id))
 	if err != nil {
 		WriteJSONError(w, http.StatusNotFound, err.Error())
 		return
@@ -80,6 +131,133 @@ func (h *DocumentHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ListDocuments returns a page of documents. Supported query parameters: folder_id,
+// document_type_id, unfiled=true, from and to (RFC 3339, creation time), min_sheets,
+// max_sheets, sort (created_at, title, sheets_count or id, "-" prefix for descending),
+// limit and cursor (next_cursor or prev_cursor of the previous page).
+func (h *DocumentHandler) ListDocuments(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := service.DocumentListQuery{
+		Cursor: params.Get("cursor"),
+		Limit:  defaultPageSize,
+	}
+
+	var err error
+	if query.FolderID, err = parseOptionalUint(params.Get("folder_id")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder_id")
+		return
+	}
+	if query.DocumentTypeID, err = parseOptionalUint(params.Get("document_type_id")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid document_type_id")
+		return
+	}
+	if v := params.Get("unfiled"); v != "" {
+		if query.Unfiled, err = strconv.ParseBool(v); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid unfiled")
+			return
+		}
+	}
+	if query.From, err = parseOptionalTime(params.Get("from")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid from, expected RFC 3339 time")
+		return
+	}
+	if query.To, err = parseOptionalTime(params.Get("to")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid to, expected RFC 3339 time")
+		return
+	}
+	if query.MinSheets, err = parseOptionalInt(params.Get("min_sheets")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid min_sheets")
+		return
+	}
+	if query.MaxSheets, err = parseOptionalInt(params.Get("max_sheets")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid max_sheets")
+		return
+	}
+	if v := params.Get("sort"); v != "" {
+		query.SortBy = strings.TrimPrefix(v, "-")
+		query.Desc = strings.HasPrefix(v, "-")
+	}
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxPageSize {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		query.Limit = limit
+	}
+
+	page, err := h.documentService.ListDocuments(actorFromRequest(r), query)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidSortKey) {
+			status = http.StatusBadRequest
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+// SearchDocuments runs a full-text search over titles and file names. Supported query
+// parameters: q (required, web search syntax), fuzzy=true for typo-tolerant title
+// matching, folder_id, document_type_id, from and to (RFC 3339, creation time) and limit.
+func (h *DocumentHandler) SearchDocuments(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := service.DocumentSearchFilter{
+		Query: strings.TrimSpace(query.Get("q")),
+		Limit: defaultSearchLimit,
+	}
+	if filter.Query == "" {
+		WriteJSONError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	var err error
+	if v := query.Get("fuzzy"); v != "" {
+		if filter.Fuzzy, err = strconv.ParseBool(v); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid fuzzy")
+			return
+		}
+	}
+	if filter.FolderID, err = parseOptionalUint(query.Get("folder_id")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder_id")
+		return
+	}
+	if filter.DocumentTypeID, err = parseOptionalUint(query.Get("document_type_id")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid document_type_id")
+		return
+	}
+	if filter.From, err = parseOptionalTime(query.Get("from")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid from, expected RFC 3339 time")
+		return
+	}
+	if filter.To, err = parseOptionalTime(query.Get("to")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid to, expected RFC 3339 time")
+		return
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxSearchLimit {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	result, err := h.documentService.SearchDocuments(actorFromRequest(r), filter)
+	if err != nil {
+		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
 func (h *DocumentHandler) UpdateDocument(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -94,9 +272,27 @@ func (h *DocumentHandler) UpdateDocument(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	document, err := h.documentService.UpdateDocument(uint(id), req.Title, req.SheetsCount, req.FolderID)
+	if req.SheetsCount != nil && *req.SheetsCount <= 0 {
+		WriteJSONError(w, http.StatusBadRequest, "sheets_count must be positive")
+		return
+	}
+
+	override, err := overrideCheckout(r)
 	if err != nil {
-		WriteJSONError(w, http.StatusBadRequest, err.Error())
+		WriteJSONError(w, http.StatusBadRequest, "Invalid override_checkout")
+		return
+	}
+
+	document, err := h.documentService.UpdateDocument(actorFromRequest(r), uint(id), req.Title, req.SheetsCount, req.FolderID, override)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, service.ErrDocumentNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrFolderCheckedOut):
+			status = http.StatusConflict
+		}
+		WriteJSONError(w, status, err.Error())
 		return
 	}
 
@@ -113,11 +309,190 @@ func (h *DocumentHandler) DeleteDocument(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = h.documentService.DeleteDocument(uint(id))
+	err = h.documentService.DeleteDocument(actorFromRequest(r), uint(id))
 	if err != nil {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrDocumentNotFound) {
+			status = http.StatusNotFound
+		}
+		WriteJSONError(w, status, err.Error())
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *DocumentHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid document ID")
+		return
+	}
+
+	versions, err := h.documentService.ListVersions(actorFromRequest(r), uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrDocumentNotFound) {
+			status = http.StatusNotFound
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(versions)
+}
+
+func (h *DocumentHandler) RestoreVersion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid document ID")
+		return
+	}
+
+	version, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil || version <= 0 {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid version number")
+		return
+	}
+
+	override, err := overrideCheckout(r)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid override_checkout")
+		return
+	}
+
+	document, err := h.documentService.RestoreVersion(actorFromRequest(r), uint(id), version, override)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, service.ErrDocumentNotFound) || errors.Is(err, service.ErrVersionNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrFolderCheckedOut):
+			status = http.StatusConflict
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(document)
+}
+
+// UploadFile attaches the "file" part of a multipart/form-data request to the document.
+func (h *DocumentHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid document ID")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid multipart body or file too large")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "file part is required")
+		return
+	}
+	defer file.Close()
+
+	document, err := h.documentService.AttachFile(actorFromRequest(r), uint(id), header.Filename, header.Header.Get("Content-Type"), file)
+	if err != nil {
+		var duplicateErr *service.DuplicateFileError
+		if errors.As(err, &duplicateErr) {
+			writeDuplicateFileError(w, duplicateErr)
+			return
+		}
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrDocumentNotFound) {
+			status = http.StatusNotFound
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(document)
+}
+
+func (h *DocumentHandler) DownloadFile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid document ID")
+		return
+	}
+
+	document, content, err := h.documentService.OpenFile(actorFromRequest(r), uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrDocumentNotFound) || errors.Is(err, service.ErrNoFile) {
+			status = http.StatusNotFound
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", document.FileMIMEType)
+	w.Header().Set("Content-Length", strconv.FormatInt(document.FileSize, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": document.FileName}))
+	w.Header().Set("ETag", `"`+document.FileHash+`"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, content)
+}
+
+// SetExpiry sets the date a document stops being in force, which starts the
+// retention period of expiry-based rules.
+func (h *DocumentHandler) SetExpiry(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid document ID")
+		return
+	}
+
+	var req SetExpiryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	document, err := h.documentService.SetExpiry(actorFromRequest(r), uint(id), req.ExpiresAt)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrDocumentNotFound) {
+			status = http.StatusNotFound
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(document)
+}
+
+// writeDuplicateFileError answers 409 with the existing documents that have the same content.
+func writeDuplicateFileError(w http.ResponseWriter, err *service.DuplicateFileError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":        err.Error(),
+		"duplicate_of": err.DuplicateOf,
+	})
+}
+
+func isSHA256Hex(value string) bool {
+	if len(value) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
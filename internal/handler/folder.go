@@ -2,10 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"folder-system/internal/service"
+
+	"github.com/go-chi/chi/v5"
 )
 
 type FolderHandler struct {
@@ -16,6 +20,167 @@ func NewFolderHandler(folderService service.FolderService) *FolderHandler {
 	return &FolderHandler{folderService: folderService}
 }
 
+type CreateFolderRequest struct {
+	Name         string `json:"name"`
+	TotalSheets  int    `json:"total_sheets"`
+	FolderTypeID uint   `json:"folder_type_id"`
+}
+
+type UpdateFolderRequest struct {
+	Name         *string `json:"name,omitempty"`
+	TotalSheets  *int    `json:"total_sheets,omitempty"`
+	FolderTypeID *uint   `json:"folder_type_id,omitempty"`
+}
+
+func (h *FolderHandler) CreateFolder(w http.ResponseWriter, r *http.Request) {
+	var req CreateFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Name == "" || req.FolderTypeID == 0 || req.TotalSheets < 0 {
+		WriteJSONError(w, http.StatusBadRequest, "Name, folder_type_id and non-negative total_sheets are required")
+		return
+	}
+
+	folder, err := h.folderService.CreateFolder(actorFromRequest(r), req.Name, req.TotalSheets, req.FolderTypeID)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrForbidden) {
+			status = http.StatusForbidden
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(folder)
+}
+
+// ListFolders returns folders with their fill level. Supported query parameters:
+// folder_type_id, min_free_sheets and sort (id, name, fill or free_sheets, "-"
+// prefix for descending), e.g. ?folder_type_id=3&sort=-fill for the fullest Legal folders.
+func (h *FolderHandler) ListFolders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter service.FolderListFilter
+
+	var err error
+	if filter.FolderTypeID, err = parseOptionalUint(query.Get("folder_type_id")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder_type_id")
+		return
+	}
+	if filter.MinFreeSheets, err = parseOptionalInt(query.Get("min_free_sheets")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid min_free_sheets")
+		return
+	}
+	if v := query.Get("sort"); v != "" {
+		filter.SortBy = strings.TrimPrefix(v, "-")
+		filter.Desc = strings.HasPrefix(v, "-")
+	}
+
+	folders, err := h.folderService.ListFolders(actorFromRequest(r), filter)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidSortKey) {
+			status = http.StatusBadRequest
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(folders)
+}
+
+func (h *FolderHandler) GetFolder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder ID")
+		return
+	}
+
+	folder, err := h.folderService.GetFolder(actorFromRequest(r), uint(id))
+	if err != nil {
+		WriteJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(folder)
+}
+
+func (h *FolderHandler) UpdateFolder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder ID")
+		return
+	}
+
+	var req UpdateFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if (req.Name != nil && *req.Name == "") || (req.TotalSheets != nil && *req.TotalSheets <= 0) {
+		WriteJSONError(w, http.StatusBadRequest, "Name must not be empty and total_sheets must be positive")
+		return
+	}
+
+	folder, err := h.folderService.UpdateFolder(actorFromRequest(r), uint(id), req.Name, req.TotalSheets, req.FolderTypeID)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, service.ErrFolderNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrForbidden):
+			status = http.StatusForbidden
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(folder)
+}
+
+// DeleteFolder removes a folder. The "policy" query parameter (restrict, detach or cascade)
+// controls what happens to documents still filed in it; restrict is the default.
+func (h *FolderHandler) DeleteFolder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder ID")
+		return
+	}
+
+	policy := service.DeleteRestrict
+	if p := r.URL.Query().Get("policy"); p != "" {
+		policy = service.FolderDeletePolicy(p)
+	}
+
+	err = h.folderService.DeleteFolder(actorFromRequest(r), uint(id), policy)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrFolderNotFound):
+			WriteJSONError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrFolderNotEmpty):
+			WriteJSONError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrInvalidDeletePolicy):
+			WriteJSONError(w, http.StatusBadRequest, err.Error())
+		default:
+			WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *FolderHandler) GetRecommendedFolder(w http.ResponseWriter, r *http.Request) {
 	docTypeIDStr := r.URL.Query().Get("document_type_id")
 	sheetsCountStr := r.URL.Query().Get("sheets_count")
@@ -41,7 +206,21 @@ func (h *FolderHandler) GetRecommendedFolder(w http.ResponseWriter, r *http.Requ
 		sheetsCount = int(sc)
 	}
 
-	folder, err := h.folderService.GetRecommendedFolder(uint(docTypeID), sheetsCount)
+	within, err := parseOptionalUint(r.URL.Query().Get("within"))
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid within")
+		return
+	}
+
+	folder, err := h.folderService.GetRecommendedFolder(actorFromRequest(r), uint(docTypeID), sheetsCount, r.URL.Query().Get("strategy"), within)
+	if errors.Is(err, service.ErrUnknownStrategy) {
+		WriteJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrContainerNotFound) {
+		WriteJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
 	if err != nil {
 		// Not found is acceptable — return JSON null
 		w.Header().Set("Content-Type", "application/json")
@@ -57,3 +236,190 @@ func (h *FolderHandler) GetRecommendedFolder(w http.ResponseWriter, r *http.Requ
 		return
 	}
 }
+
+// MoveFolderRequest puts a folder into a container; a container_id of 0 or null
+// takes it out of its container.
+type MoveFolderRequest struct {
+	ContainerID *uint `json:"container_id"`
+}
+
+func (h *FolderHandler) MoveFolder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder ID")
+		return
+	}
+
+	var req MoveFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	folder, err := h.folderService.MoveFolder(actorFromRequest(r), uint(id), req.ContainerID)
+	if err != nil {
+		writeContainerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(folder)
+}
+
+// GetFolderLocation returns the containers a folder is kept in, outermost first.
+func (h *FolderHandler) GetFolderLocation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder ID")
+		return
+	}
+
+	location, err := h.folderService.GetFolderLocation(actorFromRequest(r), uint(id))
+	if err != nil {
+		writeContainerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(location)
+}
+
+// AssignFolderLocationRequest is the physical address a folder has been put at.
+type AssignFolderLocationRequest struct {
+	Site     string `json:"site"`
+	Room     string `json:"room"`
+	Rack     string `json:"rack"`
+	Shelf    string `json:"shelf"`
+	Position string `json:"position"`
+	Note     string `json:"note"`
+}
+
+func (h *FolderHandler) AssignFolderLocation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder ID")
+		return
+	}
+
+	var req AssignFolderLocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	location, err := h.folderService.AssignFolderLocation(actorFromRequest(r), uint(id), service.LocationAddress{
+		Site:     req.Site,
+		Room:     req.Room,
+		Rack:     req.Rack,
+		Shelf:    req.Shelf,
+		Position: req.Position,
+		Note:     req.Note,
+	})
+	if err != nil {
+		writeContainerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(location)
+}
+
+// ListFolderLocations returns the location history of a folder, newest first.
+func (h *FolderHandler) ListFolderLocations(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder ID")
+		return
+	}
+
+	locations, err := h.folderService.ListFolderLocations(actorFromRequest(r), uint(id))
+	if err != nil {
+		writeContainerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(locations)
+}
+
+type ShareFolderRequest struct {
+	UserID     *uint  `json:"user_id,omitempty"`
+	GroupID    *uint  `json:"group_id,omitempty"`
+	Permission string `json:"permission"`
+}
+
+func (h *FolderHandler) ShareFolder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder ID")
+		return
+	}
+
+	var req ShareFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	grant, err := h.folderService.ShareFolder(actorFromRequest(r), uint(id), req.UserID, req.GroupID, req.Permission)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrFolderNotFound) || errors.Is(err, service.ErrUserNotFound) || errors.Is(err, service.ErrGroupNotFound) {
+			status = http.StatusNotFound
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(grant)
+}
+
+func (h *FolderHandler) ListFolderGrants(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder ID")
+		return
+	}
+
+	grants, err := h.folderService.ListFolderGrants(actorFromRequest(r), uint(id))
+	if err != nil {
+		WriteJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(grants)
+}
+
+func (h *FolderHandler) RevokeFolderGrant(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder ID")
+		return
+	}
+
+	grantID, err := strconv.ParseUint(chi.URLParam(r, "grantID"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid grant ID")
+		return
+	}
+
+	err = h.folderService.RevokeFolderGrant(actorFromRequest(r), uint(id), uint(grantID))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrFolderNotFound) || errors.Is(err, service.ErrGrantNotFound) {
+			status = http.StatusNotFound
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -5,16 +5,38 @@ import (
 )
 
 type Handler struct {
-	auth     *AuthHandler
-	document *DocumentHandler
-	folder   *FolderHandler
+	auth       *AuthHandler
+	document   *DocumentHandler
+	folder     *FolderHandler
+	container  *ContainerHandler
+	assignment *AssignmentHandler
+	types      *TypeHandler
+	group      *GroupHandler
+	audit      *AuditHandler
+	upload     *UploadHandler
+	loan       *LoanHandler
+	label      *LabelHandler
+	scan       *ScanHandler
+	inventory  *InventoryHandler
+	disposal   *DisposalHandler
 }
 
 func NewHandler(services *service.Service) *Handler {
 	return &Handler{
-		auth:     NewAuthHandler(services.Auth),
-		document: NewDocumentHandler(services.Document),
-		folder:   NewFolderHandler(services.Folder),
+		auth:       NewAuthHandler(services.Auth),
+		document:   NewDocumentHandler(services.Document),
+		folder:     NewFolderHandler(services.Folder),
+		container:  NewContainerHandler(services.Container),
+		assignment: NewAssignmentHandler(services.Assignment),
+		types:      NewTypeHandler(services.Type),
+		group:      NewGroupHandler(services.Group),
+		audit:      NewAuditHandler(services.Audit),
+		upload:     NewUploadHandler(services.Upload),
+		loan:       NewLoanHandler(services.Loan),
+		label:      NewLabelHandler(services.Label),
+		scan:       NewScanHandler(services.Scan),
+		inventory:  NewInventoryHandler(services.Inventory),
+		disposal:   NewDisposalHandler(services.Disposal),
 	}
 }
 
@@ -29,3 +51,47 @@ func (h *Handler) DocumentHandler() *DocumentHandler {
 func (h *Handler) FolderHandler() *FolderHandler {
 	return h.folder
 }
+
+func (h *Handler) ContainerHandler() *ContainerHandler {
+	return h.container
+}
+
+func (h *Handler) AssignmentHandler() *AssignmentHandler {
+	return h.assignment
+}
+
+func (h *Handler) TypeHandler() *TypeHandler {
+	return h.types
+}
+
+func (h *Handler) GroupHandler() *GroupHandler {
+	return h.group
+}
+
+func (h *Handler) AuditHandler() *AuditHandler {
+	return h.audit
+}
+
+func (h *Handler) UploadHandler() *UploadHandler {
+	return h.upload
+}
+
+func (h *Handler) LoanHandler() *LoanHandler {
+	return h.loan
+}
+
+func (h *Handler) LabelHandler() *LabelHandler {
+	return h.label
+}
+
+func (h *Handler) ScanHandler() *ScanHandler {
+	return h.scan
+}
+
+func (h *Handler) InventoryHandler() *InventoryHandler {
+	return h.inventory
+}
+
+func (h *Handler) DisposalHandler() *DisposalHandler {
+	return h.disposal
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"folder-system/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type InventoryHandler struct {
+	inventoryService service.InventoryService
+}
+
+func NewInventoryHandler(inventoryService service.InventoryService) *InventoryHandler {
+	return &InventoryHandler{inventoryService: inventoryService}
+}
+
+type StartInventoryRequest struct {
+	Note string `json:"note"`
+}
+
+// RecordFolderRequest lists the label codes scanned in a folder. An empty list
+// records the folder as found empty.
+type RecordFolderRequest struct {
+	Codes []string `json:"codes"`
+}
+
+// ReconcileRequest selects the discrepancies to fix; an empty list fixes all of them.
+type ReconcileRequest struct {
+	DocumentIDs []uint `json:"document_ids"`
+}
+
+func (h *InventoryHandler) StartInventory(w http.ResponseWriter, r *http.Request) {
+	var req StartInventoryRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	session, err := h.inventoryService.StartInventory(actorFromRequest(r), req.Note)
+	if err != nil {
+		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(session)
+}
+
+func (h *InventoryHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid inventory session ID")
+		return
+	}
+
+	report, err := h.inventoryService.GetReport(actorFromRequest(r), uint(id))
+	if err != nil {
+		writeInventoryError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+func (h *InventoryHandler) RecordFolder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid inventory session ID")
+		return
+	}
+	folderID, err := strconv.ParseUint(chi.URLParam(r, "folderID"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder ID")
+		return
+	}
+
+	var req RecordFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	report, err := h.inventoryService.RecordFolder(actorFromRequest(r), uint(id), uint(folderID), req.Codes)
+	if err != nil {
+		writeInventoryError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+func (h *InventoryHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid inventory session ID")
+		return
+	}
+
+	var req ReconcileRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	override, err := overrideCheckout(r)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid override_checkout")
+		return
+	}
+
+	report, err := h.inventoryService.Reconcile(actorFromRequest(r), uint(id), req.DocumentIDs, override)
+	if err != nil {
+		writeInventoryError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+func (h *InventoryHandler) CompleteInventory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid inventory session ID")
+		return
+	}
+
+	session, err := h.inventoryService.CompleteInventory(actorFromRequest(r), uint(id))
+	if err != nil {
+		writeInventoryError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(session)
+}
+
+func writeInventoryError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInventoryNotFound), errors.Is(err, service.ErrFolderNotFound),
+		errors.Is(err, service.ErrDocumentNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrInventoryCompleted), errors.Is(err, service.ErrNotEnoughSpace),
+		errors.Is(err, service.ErrFolderCheckedOut):
+		status = http.StatusConflict
+	}
+	WriteJSONError(w, status, err.Error())
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"folder-system/internal/label"
+	"folder-system/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultLabelColumns is how many labels a sheet has per row unless asked otherwise.
+const defaultLabelColumns = 3
+
+type LabelHandler struct {
+	labelService service.LabelService
+}
+
+func NewLabelHandler(labelService service.LabelService) *LabelHandler {
+	return &LabelHandler{labelService: labelService}
+}
+
+// labelOptions reads symbology (qr or code128, default qr) and format (png or svg,
+// default png) from the query.
+func labelOptions(r *http.Request) (symbology, format string) {
+	symbology = r.URL.Query().Get("symbology")
+	if symbology == "" {
+		symbology = label.SymbologyQR
+	}
+	format = r.URL.Query().Get("format")
+	if format == "" {
+		format = label.FormatPNG
+	}
+	return symbology, format
+}
+
+func (h *LabelHandler) FolderLabel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder ID")
+		return
+	}
+
+	symbology, format := labelOptions(r)
+	labels, err := h.labelService.FolderLabels(actorFromRequest(r), []uint{uint(id)}, symbology)
+	if err != nil {
+		writeLabelError(w, err)
+		return
+	}
+	writeLabels(w, format, labels, 1)
+}
+
+func (h *LabelHandler) DocumentLabel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid document ID")
+		return
+	}
+
+	symbology, format := labelOptions(r)
+	l, err := h.labelService.DocumentLabel(actorFromRequest(r), uint(id), symbology)
+	if err != nil {
+		writeLabelError(w, err)
+		return
+	}
+	writeLabels(w, format, []*label.Label{l}, 1)
+}
+
+// FolderLabelSheet prints the labels of the folders listed in ids (comma-separated)
+// on one sheet with the given number of columns.
+func (h *LabelHandler) FolderLabelSheet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var ids []uint
+	for _, v := range strings.Split(query.Get("ids"), ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid ids")
+			return
+		}
+		ids = append(ids, uint(id))
+	}
+	if len(ids) == 0 {
+		WriteJSONError(w, http.StatusBadRequest, "ids is required")
+		return
+	}
+
+	columns := defaultLabelColumns
+	if v := query.Get("columns"); v != "" {
+		var err error
+		if columns, err = strconv.Atoi(v); err != nil || columns <= 0 {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid columns")
+			return
+		}
+	}
+
+	symbology, format := labelOptions(r)
+	labels, err := h.labelService.FolderLabels(actorFromRequest(r), ids, symbology)
+	if err != nil {
+		writeLabelError(w, err)
+		return
+	}
+	writeLabels(w, format, labels, columns)
+}
+
+// writeLabels renders the labels in memory first so a failure can still be
+// reported as a JSON error.
+func writeLabels(w http.ResponseWriter, format string, labels []*label.Label, columns int) {
+	contentType := label.ContentType(format)
+	if contentType == "" {
+		WriteJSONError(w, http.StatusBadRequest, label.ErrUnknownFormat.Error())
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := label.Render(&buf, format, labels, columns); err != nil {
+		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = buf.WriteTo(w)
+}
+
+func writeLabelError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrFolderNotFound), errors.Is(err, service.ErrDocumentNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrTooManyLabels), errors.Is(err, label.ErrUnknownSymbology),
+		errors.Is(err, label.ErrUnsupportedCharacter), errors.Is(err, label.ErrDataTooLong):
+		status = http.StatusBadRequest
+	}
+	WriteJSONError(w, status, err.Error())
+}
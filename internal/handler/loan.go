@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"folder-system/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type LoanHandler struct {
+	loanService service.LoanService
+}
+
+func NewLoanHandler(loanService service.LoanService) *LoanHandler {
+	return &LoanHandler{loanService: loanService}
+}
+
+// CheckOutRequest lends either a folder or a single document. A missing
+// borrower_id lends the item to the caller.
+type CheckOutRequest struct {
+	FolderID   *uint     `json:"folder_id,omitempty"`
+	DocumentID *uint     `json:"document_id,omitempty"`
+	BorrowerID uint      `json:"borrower_id"`
+	DueAt      time.Time `json:"due_at"`
+	Note       string    `json:"note"`
+}
+
+func (h *LoanHandler) CheckOut(w http.ResponseWriter, r *http.Request) {
+	var req CheckOutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	loan, err := h.loanService.CheckOut(actorFromRequest(r), req.FolderID, req.DocumentID, req.BorrowerID, req.DueAt, req.Note)
+	if err != nil {
+		writeLoanError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(loan)
+}
+
+func (h *LoanHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid loan ID")
+		return
+	}
+
+	loan, err := h.loanService.CheckIn(actorFromRequest(r), uint(id))
+	if err != nil {
+		writeLoanError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(loan)
+}
+
+// ListLoans supports the query parameters active=true, overdue=true, folder_id
+// and document_id. Overdue loans are always active ones.
+func (h *LoanHandler) ListLoans(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter service.LoanFilter
+	var err error
+
+	if v := query.Get("active"); v != "" {
+		if filter.ActiveOnly, err = strconv.ParseBool(v); err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid active")
+			return
+		}
+	}
+	if v := query.Get("overdue"); v != "" {
+		overdue, err := strconv.ParseBool(v)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid overdue")
+			return
+		}
+		if overdue {
+			now := time.Now()
+			filter.OverdueAt = &now
+		}
+	}
+	if filter.FolderID, err = parseOptionalUint(query.Get("folder_id")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid folder_id")
+		return
+	}
+	if filter.DocumentID, err = parseOptionalUint(query.Get("document_id")); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid document_id")
+		return
+	}
+
+	loans, err := h.loanService.ListLoans(actorFromRequest(r), filter)
+	if err != nil {
+		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(loans)
+}
+
+func writeLoanError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrLoanNotFound), errors.Is(err, service.ErrFolderNotFound),
+		errors.Is(err, service.ErrDocumentNotFound), errors.Is(err, service.ErrUserNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrAlreadyCheckedOut), errors.Is(err, service.ErrLoanReturned),
+		errors.Is(err, service.ErrDocumentsOnLoan):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrInvalidLoan):
+		status = http.StatusBadRequest
+	}
+	WriteJSONError(w, status, err.Error())
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"folder-system/internal/label"
+	"folder-system/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ScanHandler struct {
+	scanService service.ScanService
+}
+
+func NewScanHandler(scanService service.ScanService) *ScanHandler {
+	return &ScanHandler{scanService: scanService}
+}
+
+// ScanRequest carries the text a barcode reader produced.
+type ScanRequest struct {
+	Code string `json:"code"`
+}
+
+// Lookup resolves a scanned label code such as FLD-00000012 to its folder or document.
+func (h *ScanHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	result, err := h.scanService.Lookup(actorFromRequest(r), chi.URLParam(r, "code"))
+	if err != nil {
+		writeScanError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func (h *ScanHandler) StartScanSession(w http.ResponseWriter, r *http.Request) {
+	session, err := h.scanService.StartScanSession(actorFromRequest(r))
+	if err != nil {
+		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(session)
+}
+
+func (h *ScanHandler) Scan(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid scan session ID")
+		return
+	}
+
+	var req ScanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	override, err := overrideCheckout(r)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid override_checkout")
+		return
+	}
+
+	result, err := h.scanService.Scan(actorFromRequest(r), uint(id), req.Code, override)
+	if err != nil {
+		writeScanError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func (h *ScanHandler) CloseScanSession(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid scan session ID")
+		return
+	}
+
+	session, err := h.scanService.CloseScanSession(actorFromRequest(r), uint(id))
+	if err != nil {
+		writeScanError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(session)
+}
+
+func writeScanError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, label.ErrInvalidCode):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrScanSessionNotFound), errors.Is(err, service.ErrFolderNotFound),
+		errors.Is(err, service.ErrDocumentNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrScanSessionClosed), errors.Is(err, service.ErrNoFolderScanned),
+		errors.Is(err, service.ErrNotEnoughSpace), errors.Is(err, service.ErrFolderCheckedOut):
+		status = http.StatusConflict
+	}
+	WriteJSONError(w, status, err.Error())
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"folder-system/internal/entity"
+	"folder-system/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type TypeHandler struct {
+	typeService service.TypeService
+}
+
+func NewTypeHandler(typeService service.TypeService) *TypeHandler {
+	return &TypeHandler{typeService: typeService}
+}
+
+type CreateTypeRequest struct {
+	Name string `json:"name"`
+}
+
+// SetRetentionRequest sets how long documents of a type are kept. The trigger
+// defaults to the filing date.
+type SetRetentionRequest struct {
+	RetentionMonths  int    `json:"retention_months"`
+	RetentionTrigger string `json:"retention_trigger"`
+}
+
+func (h *TypeHandler) CreateDocumentType(w http.ResponseWriter, r *http.Request) {
+	var req CreateTypeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		WriteJSONError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	documentType, err := h.typeService.CreateDocumentType(req.Name)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(documentType)
+}
+
+func (h *TypeHandler) ListDocumentTypes(w http.ResponseWriter, r *http.Request) {
+	documentTypes, err := h.typeService.ListDocumentTypes()
+	if err != nil {
+		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(documentTypes)
+}
+
+func (h *TypeHandler) SetRetention(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid document type ID")
+		return
+	}
+
+	var req SetRetentionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.RetentionTrigger == "" {
+		req.RetentionTrigger = entity.RetentionTriggerCreated
+	}
+
+	documentType, err := h.typeService.SetRetention(actorFromRequest(r), uint(id), req.RetentionMonths, req.RetentionTrigger)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrDocumentTypeNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrInvalidRetention):
+			status = http.StatusBadRequest
+		}
+		WriteJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(documentType)
+}
+
+func (h *TypeHandler) CreateFolderType(w http.ResponseWriter, r *http.Request) {
+	var req CreateTypeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		WriteJSONError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	folderType, err := h.typeService.CreateFolderType(req.Name)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(folderType)
+}
+
+func (h *TypeHandler) ListFolderTypes(w http.ResponseWriter, r *http.Request) {
+	folderTypes, err := h.typeService.ListFolderTypes()
+	if err != nil {
+		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(folderTypes)
+}
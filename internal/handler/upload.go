@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"folder-system/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// tusVersion is the tus resumable upload protocol version the upload endpoints follow.
+const tusVersion = "1.0.0"
+
+// UploadHandler serves resumable uploads using the headers of the tus protocol:
+// Upload-Length and Upload-Metadata on creation, Upload-Offset on every chunk.
+type UploadHandler struct {
+	uploadService service.UploadService
+}
+
+func NewUploadHandler(uploadService service.UploadService) *UploadHandler {
+	return &UploadHandler{uploadService: uploadService}
+}
+
+// CreateUpload starts a resumable upload for the document. The Location header
+// points to the upload URL chunks are sent to.
+func (h *UploadHandler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid document ID")
+		return
+	}
+
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Upload-Length header is required")
+		return
+	}
+	if length > maxUploadSize {
+		WriteJSONError(w, http.StatusRequestEntityTooLarge, "Upload is too large")
+		return
+	}
+	metadata, err := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid Upload-Metadata header")
+		return
+	}
+
+	upload, err := h.uploadService.CreateUpload(actorFromRequest(r), uint(id), length, metadata["filename"], metadata["filetype"])
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Location", "/api/protected/uploads/"+upload.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(upload)
+}
+
+// GetUploadOffset reports how many bytes have been received so a client can resume.
+func (h *UploadHandler) GetUploadOffset(w http.ResponseWriter, r *http.Request) {
+	upload, err := h.uploadService.GetUpload(actorFromRequest(r), chi.URLParam(r, "uploadID"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+// PatchUpload appends the request body at the offset given in Upload-Offset.
+func (h *UploadHandler) PatchUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		WriteJSONError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/offset+octet-stream")
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		WriteJSONError(w, http.StatusBadRequest, "Upload-Offset header is required")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	upload, err := h.uploadService.AppendChunk(actorFromRequest(r), chi.URLParam(r, "uploadID"), offset, r.Body)
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FinalizeUpload attaches a fully received upload to its document. Uploads are
+// finalized with the last chunk already; this retries a finalization that failed.
+func (h *UploadHandler) FinalizeUpload(w http.ResponseWriter, r *http.Request) {
+	document, err := h.uploadService.FinalizeUpload(actorFromRequest(r), chi.URLParam(r, "uploadID"))
+	if err != nil {
+		writeUploadError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(document)
+}
+
+func (h *UploadHandler) AbortUpload(w http.ResponseWriter, r *http.Request) {
+	if err := h.uploadService.AbortUpload(actorFromRequest(r), chi.URLParam(r, "uploadID")); err != nil {
+		writeUploadError(w, err)
+		return
+	}
+
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeUploadError(w http.ResponseWriter, err error) {
+	var duplicateErr *service.DuplicateFileError
+	if errors.As(err, &duplicateErr) {
+		writeDuplicateFileError(w, duplicateErr)
+		return
+	}
+
+	status := http.StatusInternalServerError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound), errors.Is(err, service.ErrDocumentNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrUploadOffset), errors.Is(err, service.ErrUploadCompleted), errors.Is(err, service.ErrUploadIncomplete):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrUploadTooLarge), errors.As(err, &maxBytesErr):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrInvalidUploadSize):
+		status = http.StatusBadRequest
+	}
+	WriteJSONError(w, status, err.Error())
+}
+
+// parseUploadMetadata decodes the tus Upload-Metadata header: comma separated
+// pairs of a key and a base64 encoded value.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if header == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
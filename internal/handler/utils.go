@@ -1,13 +1,69 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
+
+	"folder-system/internal/service"
+	"folder-system/internal/utils"
 )
 
 // WriteJSONError пишет JSON-ответ с ошибкой и соответствующим статусом HTTP.
 func WriteJSONError(w http.ResponseWriter, status int, errMsg string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(map[string]string{"error": errMsg})
+	utils.WriteJSONError(w, status, errMsg)
+}
+
+// actorFromRequest returns the authenticated user put into the context by AuthMiddleware.
+func actorFromRequest(r *http.Request) service.Actor {
+	userID, _ := utils.UserIDFromContext(r.Context())
+	role, _ := utils.RoleFromContext(r.Context())
+	return service.Actor{UserID: userID, Role: role}
+}
+
+// parseOptionalUint parses an optional numeric ID from a query parameter.
+func parseOptionalUint(value string) (*uint, error) {
+	if value == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	id := uint(v)
+	return &id, nil
+}
+
+// parseOptionalInt parses an optional integer from a query parameter.
+func parseOptionalInt(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// parseOptionalTime parses an optional RFC 3339 timestamp from a query parameter.
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// overrideCheckout reads the override_checkout query parameter that allows filing
+// into a folder that is checked out.
+func overrideCheckout(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("override_checkout")
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
 }
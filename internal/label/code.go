@@ -0,0 +1,51 @@
+package label
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kinds of items a label code can point to.
+const (
+	KindFolder   = "folder"
+	KindDocument = "document"
+)
+
+const (
+	folderPrefix   = "FLD-"
+	documentPrefix = "DOC-"
+)
+
+var ErrInvalidCode = errors.New("not a folder or document label code")
+
+// FolderCode is the identifier printed on a folder label. It only depends on the
+// folder ID, so a label stays valid when the folder is renamed or moved.
+func FolderCode(id uint) string {
+	return fmt.Sprintf("%s%08d", folderPrefix, id)
+}
+
+// DocumentCode is the identifier printed on a document label.
+func DocumentCode(id uint) string {
+	return fmt.Sprintf("%s%08d", documentPrefix, id)
+}
+
+// ParseCode returns the kind and ID encoded in a scanned label code. Scanners often
+// add whitespace or lowercase the text, both are accepted.
+func ParseCode(code string) (kind string, id uint, err error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	switch {
+	case strings.HasPrefix(code, folderPrefix):
+		kind, code = KindFolder, code[len(folderPrefix):]
+	case strings.HasPrefix(code, documentPrefix):
+		kind, code = KindDocument, code[len(documentPrefix):]
+	default:
+		return "", 0, ErrInvalidCode
+	}
+	v, err := strconv.ParseUint(code, 10, 32)
+	if err != nil || v == 0 {
+		return "", 0, ErrInvalidCode
+	}
+	return kind, uint(v), nil
+}
@@ -0,0 +1,59 @@
+package label
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// expandWidths turns bar/space widths, starting with a bar, into modules.
+func expandWidths(widths string) string {
+	var b strings.Builder
+	for i, w := range widths {
+		digit := "0"
+		if i%2 == 0 {
+			digit = "1"
+		}
+		b.WriteString(strings.Repeat(digit, int(w-'0')))
+	}
+	return b.String()
+}
+
+func TestEncodeCode128(t *testing.T) {
+	want := expandWidths("211214" + // Start B
+		"311321" + "142112" + "241211" + "142112" + "111242" + // W i k i p
+		"112214" + "141221" + "142112" + "121124" + // e d i a
+		"421211" + // checksum 88
+		"2331112") // Stop
+	bars, err := EncodeCode128("Wikipedia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 134 {
+		t.Errorf("length = %d, want 134", len(bars))
+	}
+	if got := bitString(bars); got != want {
+		t.Errorf("bars = %s, want %s", got, want)
+	}
+}
+
+func TestEncodeCode128Length(t *testing.T) {
+	// Start, one symbol per character and checksum take 11 modules each, stop 13
+	for _, code := range []string{"", FolderCode(1), DocumentCode(12345678)} {
+		bars, err := EncodeCode128(code)
+		if err != nil {
+			t.Fatalf("%q: %v", code, err)
+		}
+		if want := 11*(len(code)+2) + 13; len(bars) != want {
+			t.Errorf("%q: length = %d, want %d", code, len(bars), want)
+		}
+	}
+}
+
+func TestEncodeCode128UnsupportedCharacter(t *testing.T) {
+	for _, code := range []string{"FLD-\n1", "DOC-\x7f", "Ordner-Ä"} {
+		if _, err := EncodeCode128(code); !errors.Is(err, ErrUnsupportedCharacter) {
+			t.Errorf("%q: err = %v, want ErrUnsupportedCharacter", code, err)
+		}
+	}
+}
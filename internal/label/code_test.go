@@ -0,0 +1,34 @@
+package label
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCodeRoundTrip(t *testing.T) {
+	for _, id := range []uint{1, 42, 99999999, 100000000, 4294967295} {
+		kind, got, err := ParseCode(FolderCode(id))
+		if err != nil || kind != KindFolder || got != id {
+			t.Errorf("ParseCode(%q) = %s, %d, %v", FolderCode(id), kind, got, err)
+		}
+		kind, got, err = ParseCode(DocumentCode(id))
+		if err != nil || kind != KindDocument || got != id {
+			t.Errorf("ParseCode(%q) = %s, %d, %v", DocumentCode(id), kind, got, err)
+		}
+	}
+}
+
+func TestParseCodeScannerInput(t *testing.T) {
+	kind, id, err := ParseCode("  fld-00000042\r\n")
+	if err != nil || kind != KindFolder || id != 42 {
+		t.Errorf("ParseCode = %s, %d, %v, want folder 42", kind, id, err)
+	}
+}
+
+func TestParseCodeInvalid(t *testing.T) {
+	for _, code := range []string{"", "FLD-", "FLD-00000000", "DOC-12a", "BOX-00000001", "FLD--0000001", "DOC-4294967296"} {
+		if _, _, err := ParseCode(code); !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("ParseCode(%q): err = %v, want ErrInvalidCode", code, err)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package label
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// The worked example of the QR code standard: "01234567" as version 1-M.
+func TestReedSolomonRemainder(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("remainder = %v, want %v", got, want)
+	}
+}
+
+// qrFormatM lists the format information of level M for masks 0-7, from the standard.
+var qrFormatM = []string{
+	"101010000010010",
+	"101000100100101",
+	"101111001111100",
+	"101101101001011",
+	"100010111111001",
+	"100000011001110",
+	"100111110010111",
+	"100101010100000",
+}
+
+// readFormat reads the format information next to the top-left finder, most
+// significant bit first, and checks that the second copy agrees.
+func readFormat(t *testing.T, m [][]bool) string {
+	t.Helper()
+	size := len(m)
+	var first, second []bool
+	for x := 0; x <= 5; x++ {
+		first = append(first, m[8][x])
+	}
+	first = append(first, m[8][7], m[8][8], m[7][8])
+	for y := 5; y >= 0; y-- {
+		first = append(first, m[y][8])
+	}
+	for y := size - 1; y >= size-7; y-- {
+		second = append(second, m[y][8])
+	}
+	for x := size - 8; x < size; x++ {
+		second = append(second, m[8][x])
+	}
+	if bitString(first) != bitString(second) {
+		t.Fatalf("format copies differ: %s and %s", bitString(first), bitString(second))
+	}
+	if !m[size-8][8] {
+		t.Error("dark module is light")
+	}
+	return bitString(first)
+}
+
+func bitString(bits []bool) string {
+	var b strings.Builder
+	for _, bit := range bits {
+		if bit {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	return b.String()
+}
+
+func TestFormatBits(t *testing.T) {
+	for mask, want := range qrFormatM {
+		qr := &qrCode{version: 1, size: 21}
+		qr.modules = newMatrix(qr.size)
+		qr.isFunction = newMatrix(qr.size)
+		qr.drawFormatBits(mask)
+		if got := readFormat(t, qr.modules); got != want {
+			t.Errorf("mask %d: format = %s, want %s", mask, got, want)
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	// Version information from the standard
+	want := map[int]int{7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3}
+	for version, bits := range want {
+		qr := &qrCode{version: version, size: 17 + 4*version}
+		qr.modules = newMatrix(qr.size)
+		qr.isFunction = newMatrix(qr.size)
+		qr.drawVersionBits()
+		got := 0
+		for i := 17; i >= 0; i-- {
+			x, y := qr.size-11+i%3, i/3
+			if qr.modules[y][x] != qr.modules[x][y] {
+				t.Fatalf("version %d: copies differ at bit %d", version, i)
+			}
+			got <<= 1
+			if qr.modules[y][x] {
+				got |= 1
+			}
+		}
+		if got != bits {
+			t.Errorf("version %d: bits = %05X, want %05X", version, got, bits)
+		}
+	}
+}
+
+// qrCapacity is the most bytes every version holds at level M.
+var qrCapacity = []int{14, 26, 42, 62, 84, 106, 122, 152, 180, 213}
+
+func TestEncodeQRVersionBoundaries(t *testing.T) {
+	for i, n := range qrCapacity {
+		version := i + 1
+		m, err := EncodeQR(bytes.Repeat([]byte("A"), n))
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if want := 17 + 4*version; len(m) != want {
+			t.Errorf("%d bytes: size = %d, want %d (version %d)", n, len(m), want, version)
+		}
+		if version == len(qrCapacity) {
+			continue
+		}
+		m, err = EncodeQR(bytes.Repeat([]byte("A"), n+1))
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n+1, err)
+		}
+		if want := 17 + 4*(version+1); len(m) != want {
+			t.Errorf("%d bytes: size = %d, want %d (version %d)", n+1, len(m), want, version+1)
+		}
+	}
+}
+
+func TestEncodeQRTooLong(t *testing.T) {
+	if _, err := EncodeQR(make([]byte, qrCapacity[len(qrCapacity)-1]+1)); !errors.Is(err, ErrDataTooLong) {
+		t.Errorf("err = %v, want ErrDataTooLong", err)
+	}
+}
+
+// decodeQR reads a symbol produced by EncodeQR back: it removes the mask named in
+// the format information, collects the codewords in placement order, checks the
+// error correction of every block and parses the byte mode segment.
+func decodeQR(t *testing.T, m [][]bool) []byte {
+	t.Helper()
+	size := len(m)
+	version := (size - 17) / 4
+	format := readFormat(t, m)
+	mask := -1
+	for i, f := range qrFormatM {
+		if f == format {
+			mask = i
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("unknown format information %s", format)
+	}
+
+	reference := &qrCode{version: version, size: size}
+	reference.modules = newMatrix(size)
+	reference.isFunction = newMatrix(size)
+	reference.drawFunctionPatterns()
+
+	masks := []func(row, col int) bool{
+		func(i, j int) bool { return (i+j)%2 == 0 },
+		func(i, j int) bool { return i%2 == 0 },
+		func(i, j int) bool { return j%3 == 0 },
+		func(i, j int) bool { return (i+j)%3 == 0 },
+		func(i, j int) bool { return (i/2+j/3)%2 == 0 },
+		func(i, j int) bool { return (i*j)%2+(i*j)%3 == 0 },
+		func(i, j int) bool { return ((i*j)%2+(i*j)%3)%2 == 0 },
+		func(i, j int) bool { return ((i+j)%2+(i*j)%3)%2 == 0 },
+	}
+
+	var bits []bool
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < size; vert++ {
+			y := vert
+			if upward {
+				y = size - 1 - vert
+			}
+			for x := right; x >= right-1; x-- {
+				if reference.isFunction[y][x] {
+					continue
+				}
+				bits = append(bits, m[y][x] != masks[mask](y, x))
+			}
+		}
+	}
+
+	v := qrVersions[version-1]
+	total := v.dataCodewords() + v.ecPerBlock*len(v.blocks)
+	codewords := make([]byte, total)
+	for i := 0; i < total*8; i++ {
+		if bits[i] {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	// Undo the interleaving
+	blocks := make([][]byte, len(v.blocks))
+	ecBlocks := make([][]byte, len(v.blocks))
+	next := 0
+	for i := 0; next < v.dataCodewords(); i++ {
+		for b, size := range v.blocks {
+			if i < size {
+				blocks[b] = append(blocks[b], codewords[next])
+				next++
+			}
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for b := range v.blocks {
+			ecBlocks[b] = append(ecBlocks[b], codewords[next])
+			next++
+		}
+	}
+	var data []byte
+	divisor := reedSolomonDivisor(v.ecPerBlock)
+	for b := range blocks {
+		if !bytes.Equal(reedSolomonRemainder(blocks[b], divisor), ecBlocks[b]) {
+			t.Fatalf("block %d: error correction does not match", b)
+		}
+		data = append(data, blocks[b]...)
+	}
+
+	if data[0]>>4 != 0x4 {
+		t.Fatalf("mode = %X, want byte mode", data[0]>>4)
+	}
+	read := func(offset, n int) int {
+		value := 0
+		for i := offset; i < offset+n; i++ {
+			value = value<<1 | int(data[i/8]>>(7-i%8)&1)
+		}
+		return value
+	}
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	length := read(4, countBits)
+	payload := make([]byte, length)
+	for i := range payload {
+		payload[i] = byte(read(4+countBits+8*i, 8))
+	}
+	return payload
+}
+
+func TestEncodeQRRoundTrip(t *testing.T) {
+	payloads := []string{
+		FolderCode(1),
+		DocumentCode(4294967295),
+		"",
+		"https://archive.example.com/folders/FLD-00001234",
+	}
+	for _, n := range qrCapacity {
+		payloads = append(payloads, strings.Repeat("z", n))
+	}
+	for _, payload := range payloads {
+		m, err := EncodeQR([]byte(payload))
+		if err != nil {
+			t.Fatalf("%q: %v", payload, err)
+		}
+		if got := decodeQR(t, m); string(got) != payload {
+			t.Errorf("decoded %q, want %q", got, payload)
+		}
+	}
+}
+
+// A label code as printed; any change to the symbol has to be deliberate.
+var goldenFolderQR = []string{
+	"#######..###..#######",
+	"#.....#..#.#..#.....#",
+	"#.###.#.###.#.#.###.#",
+	"#.###.#.#..#..#.###.#",
+	"#.###.#.#..##.#.###.#",
+	"#.....#.#.#.#.#.....#",
+	"#######.#.#.#.#######",
+	"........##.##........",
+	"#.#####..#..#.#####..",
+	"#...##.#.#..#...##...",
+	"#.#...#.#.##...#..##.",
+	".#####.##.#....#.##.#",
+	"..######.###.###..##.",
+	"........#...#...###..",
+	"#######..#..#..#..##.",
+	"#.....#.##.##..#..#..",
+	"#.###.#.##..####...##",
+	"#.###.#.#.##....##...",
+	"#.###.#.#.#.#..#.##..",
+	"#.....#.....#..#..#..",
+	"#######.###.####.#.#.",
+}
+
+func TestEncodeQRGolden(t *testing.T) {
+	m, err := EncodeQR([]byte(FolderCode(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != len(goldenFolderQR) {
+		t.Fatalf("size = %d, want %d", len(m), len(goldenFolderQR))
+	}
+	for y, row := range m {
+		got := strings.NewReplacer("1", "#", "0", ".").Replace(bitString(row))
+		if got != goldenFolderQR[y] {
+			t.Errorf("row %d = %s, want %s", y, got, goldenFolderQR[y])
+		}
+	}
+}
+
+// Versions 2-6 leave 7 remainder bits after the codewords, the others none.
+func TestEncodeQRRemainderBits(t *testing.T) {
+	want := []int{0, 7, 7, 7, 7, 7, 0, 0, 0, 0}
+	for i, v := range qrVersions {
+		qr := &qrCode{version: i + 1, size: 21 + 4*i}
+		qr.modules = newMatrix(qr.size)
+		qr.isFunction = newMatrix(qr.size)
+		qr.drawFunctionPatterns()
+		free := 0
+		for _, row := range qr.isFunction {
+			for _, function := range row {
+				if !function {
+					free++
+				}
+			}
+		}
+		codewords := v.dataCodewords() + v.ecPerBlock*len(v.blocks)
+		if got := free - codewords*8; got != want[i] {
+			t.Errorf("version %d: %d remainder bits, want %d", i+1, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package label
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// Supported symbologies and output formats.
+const (
+	SymbologyQR      = "qr"
+	SymbologyCode128 = "code128"
+
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+var (
+	ErrUnknownSymbology = errors.New("symbology must be qr or code128")
+	ErrUnknownFormat    = errors.New("format must be png or svg")
+	ErrNoLabels         = errors.New("nothing to print")
+)
+
+// Layout of a label in pixels (PNG) or user units (SVG).
+const (
+	qrModuleSize  = 6
+	barModuleSize = 2
+	barHeight     = 60
+	textScale     = 2
+	padding       = 8
+	// gutter separates the labels on a sheet.
+	gutter = 16
+)
+
+// Label is a printable label: a symbol encoding Code with Code printed below it.
+type Label struct {
+	Code    string
+	modules [][]bool
+	linear  bool
+}
+
+// New encodes code with the given symbology.
+func New(code, symbology string) (*Label, error) {
+	switch symbology {
+	case SymbologyQR:
+		modules, err := EncodeQR([]byte(code))
+		if err != nil {
+			return nil, err
+		}
+		return &Label{Code: code, modules: modules}, nil
+	case SymbologyCode128:
+		bars, err := EncodeCode128(code)
+		if err != nil {
+			return nil, err
+		}
+		return &Label{Code: code, modules: [][]bool{bars}, linear: true}, nil
+	}
+	return nil, ErrUnknownSymbology
+}
+
+// ContentType returns the MIME type of a format, or "" if it is not supported.
+func ContentType(format string) string {
+	switch format {
+	case FormatPNG:
+		return "image/png"
+	case FormatSVG:
+		return "image/svg+xml"
+	}
+	return ""
+}
+
+func (l *Label) symbolSize() (width, height int) {
+	if l.linear {
+		return (len(l.modules[0]) + 2*code128QuietZone) * barModuleSize, barHeight
+	}
+	size := (len(l.modules) + 2*qrQuietZone) * qrModuleSize
+	return size, size
+}
+
+func textSize(s string) (width, height int) {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0, 0
+	}
+	return (n*(glyphWidth+1) - 1) * textScale, glyphHeight * textScale
+}
+
+func (l *Label) size() (width, height int) {
+	symbolWidth, symbolHeight := l.symbolSize()
+	textWidth, textHeight := textSize(l.Code)
+	return max(symbolWidth, textWidth+2*padding), padding + symbolHeight + padding + textHeight + padding
+}
+
+// canvas is the drawing surface shared by the PNG and SVG output.
+type canvas interface {
+	rect(x, y, width, height int)
+	text(x, y, width, height int, s string)
+}
+
+func (l *Label) draw(c canvas, left, top int) {
+	width, _ := l.size()
+	symbolWidth, symbolHeight := l.symbolSize()
+	x0 := left + (width-symbolWidth)/2
+	y0 := top + padding
+
+	if l.linear {
+		x0 += code128QuietZone * barModuleSize
+		drawRuns(l.modules[0], func(start, n int) {
+			c.rect(x0+start*barModuleSize, y0, n*barModuleSize, barHeight)
+		})
+	} else {
+		x0 += qrQuietZone * qrModuleSize
+		y0 += qrQuietZone * qrModuleSize
+		for y, row := range l.modules {
+			drawRuns(row, func(start, n int) {
+				c.rect(x0+start*qrModuleSize, y0+y*qrModuleSize, n*qrModuleSize, qrModuleSize)
+			})
+		}
+		y0 -= qrQuietZone * qrModuleSize
+	}
+
+	textWidth, textHeight := textSize(l.Code)
+	c.text(left+(width-textWidth)/2, y0+symbolHeight+padding, textWidth, textHeight, l.Code)
+}
+
+// drawRuns calls fn for every run of dark modules in a row.
+func drawRuns(row []bool, fn func(start, n int)) {
+	for start := 0; start < len(row); {
+		if !row[start] {
+			start++
+			continue
+		}
+		end := start
+		for end < len(row) && row[end] {
+			end++
+		}
+		fn(start, end-start)
+		start = end
+	}
+}
+
+// Render writes the labels as one image, laid out in a grid with the given number
+// of columns. A single label gives a plain label image.
+func Render(w io.Writer, format string, labels []*Label, columns int) error {
+	if len(labels) == 0 {
+		return ErrNoLabels
+	}
+	columns = max(1, min(columns, len(labels)))
+	rows := (len(labels) + columns - 1) / columns
+
+	cellWidth, cellHeight := 0, 0
+	for _, l := range labels {
+		width, height := l.size()
+		cellWidth, cellHeight = max(cellWidth, width), max(cellHeight, height)
+	}
+	width := columns*cellWidth + (columns-1)*gutter
+	height := rows*cellHeight + (rows-1)*gutter
+
+	var c interface {
+		canvas
+		encode(w io.Writer) error
+	}
+	switch format {
+	case FormatPNG:
+		c = newPNGCanvas(width, height)
+	case FormatSVG:
+		c = newSVGCanvas(width, height)
+	default:
+		return ErrUnknownFormat
+	}
+
+	for i, l := range labels {
+		labelWidth, _ := l.size()
+		left := i%columns*(cellWidth+gutter) + (cellWidth-labelWidth)/2
+		top := i / columns * (cellHeight + gutter)
+		l.draw(c, left, top)
+	}
+	return c.encode(w)
+}
+
+type pngCanvas struct {
+	img *image.Gray
+}
+
+func newPNGCanvas(width, height int) *pngCanvas {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	return &pngCanvas{img: img}
+}
+
+func (c *pngCanvas) rect(x, y, width, height int) {
+	for yy := y; yy < y+height; yy++ {
+		row := c.img.Pix[yy*c.img.Stride:]
+		for xx := x; xx < x+width; xx++ {
+			row[xx] = 0
+		}
+	}
+}
+
+// text draws s with the built-in bitmap font; characters it lacks stay blank.
+func (c *pngCanvas) text(x, y, _, _ int, s string) {
+	for _, r := range s {
+		glyph := glyphs[r]
+		for row, bits := range glyph {
+			for col := 0; col < glyphWidth; col++ {
+				if bits>>(glyphWidth-1-col)&1 != 0 {
+					c.rect(x+col*textScale, y+row*textScale, textScale, textScale)
+				}
+			}
+		}
+		x += (glyphWidth + 1) * textScale
+	}
+}
+
+func (c *pngCanvas) encode(w io.Writer) error {
+	return png.Encode(w, c.img)
+}
+
+type svgCanvas struct {
+	width, height int
+	body          strings.Builder
+}
+
+func newSVGCanvas(width, height int) *svgCanvas {
+	return &svgCanvas{width: width, height: height}
+}
+
+func (c *svgCanvas) rect(x, y, width, height int) {
+	fmt.Fprintf(&c.body, `<rect x="%d" y="%d" width="%d" height="%d"/>`, x, y, width, height)
+}
+
+func (c *svgCanvas) text(x, y, width, height int, s string) {
+	fmt.Fprintf(&c.body, `<text x="%d" y="%d" font-family="monospace" font-size="%d" text-anchor="middle">%s</text>`,
+		x+width/2, y+height, height*4/3, html.EscapeString(s))
+}
+
+func (c *svgCanvas) encode(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="100%%" height="100%%" fill="#fff"/><g fill="#000">%s</g></svg>`,
+		c.width, c.height, c.width, c.height, c.body.String())
+	return err
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+
+	"folder-system/internal/utils"
+)
+
+// RequireRole lets the request through only if the role put into the context by
+// AuthMiddleware is one of roles. It must run after AuthMiddleware.
+func RequireRole(roles ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := utils.RoleFromContext(r.Context())
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			utils.WriteJSONError(w, http.StatusForbidden, "Insufficient permissions")
+		})
+	}
+}
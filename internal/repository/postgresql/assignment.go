@@ -0,0 +1,39 @@
+package postgresql
+
+import "folder-system/internal/entity"
+
+func (r *Repository) CreateFolderTypeAssignment(assignment *entity.FolderTypeAssignment) error {
+	return r.db.Create(assignment).Error
+}
+
+func (r *Repository) GetFolderTypeAssignmentByID(id uint) (*entity.FolderTypeAssignment, error) {
+	var assignment entity.FolderTypeAssignment
+	result := r.db.First(&assignment, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &assignment, nil
+}
+
+// ListFolderTypeAssignments returns assignments in preference order, optionally
+// narrowed down to a single document type.
+func (r *Repository) ListFolderTypeAssignments(documentTypeID *uint) ([]entity.FolderTypeAssignment, error) {
+	var assignments []entity.FolderTypeAssignment
+	query := r.db.Order("document_type_id, priority, id")
+	if documentTypeID != nil {
+		query = query.Where("document_type_id = ?", *documentTypeID)
+	}
+	if err := query.Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+func (r *Repository) UpdateFolderTypeAssignment(assignment *entity.FolderTypeAssignment) error {
+	return r.db.Save(assignment).Error
+}
+
+func (r *Repository) DeleteFolderTypeAssignment(id uint) error {
+	// Hard delete so the same pair can be assigned again later
+	return r.db.Unscoped().Delete(&entity.FolderTypeAssignment{}, id).Error
+}
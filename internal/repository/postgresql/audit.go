@@ -0,0 +1,98 @@
+package postgresql
+
+import (
+	"time"
+
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// auditChainLockKey is the advisory lock that serializes appends to the audit chain.
+const auditChainLockKey = 7301
+
+// auditAppendOnlySQL makes the audit table reject UPDATE and DELETE at the database level.
+const auditAppendOnlySQL = `
+CREATE OR REPLACE FUNCTION audit_records_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_records is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_records_append_only ON audit_records;
+CREATE TRIGGER audit_records_append_only
+	BEFORE UPDATE OR DELETE ON audit_records
+	FOR EACH ROW EXECUTE FUNCTION audit_records_append_only();
+`
+
+func protectAuditTrail(db *gorm.DB) error {
+	return db.Exec(auditAppendOnlySQL).Error
+}
+
+// CreateAuditRecord appends a record to the hash chain. Appends are serialized with
+// a transaction-scoped advisory lock so two writers never link to the same predecessor.
+func (r *Repository) CreateAuditRecord(record *entity.AuditRecord) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error; err != nil {
+			return err
+		}
+
+		var last entity.AuditRecord
+		if err := tx.Select("hash").Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+			return err
+		}
+
+		record.PrevHash = last.Hash
+		if record.CreatedAt.IsZero() {
+			record.CreatedAt = time.Now()
+		}
+		record.CreatedAt = record.CreatedAt.UTC().Truncate(time.Microsecond)
+
+		hash, err := record.ComputeHash()
+		if err != nil {
+			return err
+		}
+		record.Hash = hash
+
+		return tx.Create(record).Error
+	})
+}
+
+// ListAuditChain returns up to limit records with an ID greater than afterID, oldest first.
+func (r *Repository) ListAuditChain(afterID uint, limit int) ([]entity.AuditRecord, error) {
+	var records []entity.AuditRecord
+	result := r.db.Where("id > ?", afterID).Order("id").Limit(limit).Find(&records)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return records, nil
+}
+
+func (r *Repository) ListAuditRecords(filter repository.AuditFilter) ([]entity.AuditRecord, error) {
+	query := r.db.Model(&entity.AuditRecord{})
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		query = query.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var records []entity.AuditRecord
+	if err := query.Order("id DESC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,45 @@
+package postgresql
+
+import (
+	"errors"
+
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// blobLockNamespace is the first key of the per-hash advisory locks on blobs.
+const blobLockNamespace = 7302
+
+// LockBlob takes a transaction-scoped advisory lock on the hash and loads the blob.
+// The lock is held even when the blob does not exist yet, so creating and removing
+// the content of one hash never interleave. It must be called inside a transaction
+// and returns repository.ErrNotFound for an unknown hash.
+func (r *Repository) LockBlob(hash string) (*entity.Blob, error) {
+	if err := r.db.Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", blobLockNamespace, hash).Error; err != nil {
+		return nil, err
+	}
+
+	var blob entity.Blob
+	result := r.db.Where("hash = ?", hash).First(&blob)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &blob, nil
+}
+
+func (r *Repository) CreateBlob(blob *entity.Blob) error {
+	return r.db.Create(blob).Error
+}
+
+func (r *Repository) UpdateBlob(blob *entity.Blob) error {
+	return r.db.Save(blob).Error
+}
+
+func (r *Repository) DeleteBlob(hash string) error {
+	return r.db.Delete(&entity.Blob{}, "hash = ?", hash).Error
+}
@@ -0,0 +1,127 @@
+package postgresql
+
+import (
+	"folder-system/internal/entity"
+
+	"gorm.io/gorm/clause"
+)
+
+// containerPathSQL walks from a container up to its top-level ancestor.
+const containerPathSQL = `
+WITH RECURSIVE path AS (
+	SELECT containers.*, 0 AS depth FROM containers WHERE id = ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT parent.*, path.depth + 1 FROM containers parent
+	JOIN path ON parent.id = path.parent_id
+	WHERE parent.deleted_at IS NULL AND path.depth < 64
+)
+SELECT * FROM path ORDER BY depth DESC`
+
+// containerSubtreeSQL collects a container and everything nested in it. UNION
+// instead of UNION ALL keeps the walk finite even on corrupted, cyclic data.
+const containerSubtreeSQL = `
+WITH RECURSIVE subtree AS (
+	SELECT id FROM containers WHERE id = ? AND deleted_at IS NULL
+	UNION
+	SELECT child.id FROM containers child
+	JOIN subtree ON child.parent_id = subtree.id
+	WHERE child.deleted_at IS NULL
+)
+SELECT id FROM subtree`
+
+func (r *Repository) CreateContainer(container *entity.Container) error {
+	return r.db.Create(container).Error
+}
+
+func (r *Repository) GetContainerByID(id uint) (*entity.Container, error) {
+	var container entity.Container
+	result := r.db.First(&container, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &container, nil
+}
+
+// LockContainerByID loads a container with SELECT ... FOR UPDATE so that placing
+// children into it is serialized. It must be called inside a transaction.
+func (r *Repository) LockContainerByID(id uint) (*entity.Container, error) {
+	var container entity.Container
+	result := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&container, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &container, nil
+}
+
+func (r *Repository) ListContainers() ([]entity.Container, error) {
+	var containers []entity.Container
+	if err := r.db.Order("id").Find(&containers).Error; err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+func (r *Repository) UpdateContainer(container *entity.Container) error {
+	return r.db.Save(container).Error
+}
+
+func (r *Repository) DeleteContainer(id uint) error {
+	return r.db.Delete(&entity.Container{}, id).Error
+}
+
+// CountContainerChildren returns how many containers and folders sit directly in
+// each of the given containers. Empty containers are missing from the map.
+func (r *Repository) CountContainerChildren(containerIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(containerIDs))
+	if len(containerIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ParentID uint
+		Count    int64
+	}
+	result := r.db.Model(&entity.Container{}).
+		Select("parent_id, COUNT(*) AS count").
+		Where("parent_id IN ?", containerIDs).
+		Group("parent_id").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	for _, row := range rows {
+		counts[row.ParentID] += row.Count
+	}
+
+	rows = nil
+	result = r.db.Model(&entity.Folder{}).
+		Select("container_id AS parent_id, COUNT(*) AS count").
+		Where("container_id IN ?", containerIDs).
+		Group("container_id").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	for _, row := range rows {
+		counts[row.ParentID] += row.Count
+	}
+	return counts, nil
+}
+
+// ContainerPath returns the container and its ancestors, outermost first.
+func (r *Repository) ContainerPath(id uint) ([]entity.Container, error) {
+	var path []entity.Container
+	if err := r.db.Raw(containerPathSQL, id).Scan(&path).Error; err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+// ContainerSubtreeIDs returns the IDs of the container and all containers nested in it.
+func (r *Repository) ContainerSubtreeIDs(id uint) ([]uint, error) {
+	var ids []uint
+	if err := r.db.Raw(containerSubtreeSQL, id).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
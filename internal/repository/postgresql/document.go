@@ -1,15 +1,32 @@
 package postgresql
 
-import "folder-system/internal/entity"
+import (
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
 func (r *Repository) CreateDocument(document *entity.Document) error {
 	return r.db.Create(document).Error
 }
 
-func (r *Repository) GetDocumentByID(id uint) (*entity.Document, error) {
+func (r *Repository) GetDocumentByID(id uint, scope repository.AccessScope) (*entity.Document, error) {
 	var document entity.Document
 	// Preload Folder and its type to check capacity later
-	result := r.db.Preload("Folder").Preload("DocumentType").First(&document, id)
+	result := r.db.Scopes(documentAccess(scope)).Preload("Folder").Preload("DocumentType").First(&document, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &document, nil
+}
+
+// LockDocumentByID loads a document with SELECT ... FOR UPDATE, without associations.
+// It must be called inside a transaction.
+func (r *Repository) LockDocumentByID(id uint, scope repository.AccessScope) (*entity.Document, error) {
+	var document entity.Document
+	result := r.db.Scopes(documentAccess(scope)).Clauses(clause.Locking{Strength: "UPDATE"}).First(&document, id)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -17,9 +34,133 @@ func (r *Repository) GetDocumentByID(id uint) (*entity.Document, error) {
 }
 
 func (r *Repository) UpdateDocument(document *entity.Document) error {
-	return r.db.Save(document).Error
+	// Associations are skipped so a stale preloaded Folder cannot overwrite folder_id
+	return r.db.Omit(clause.Associations).Save(document).Error
 }
 
 func (r *Repository) DeleteDocument(id uint) error {
 	return r.db.Delete(&entity.Document{}, id).Error
 }
+
+func (r *Repository) ListDocumentsInFolder(folderID uint) ([]entity.Document, error) {
+	var documents []entity.Document
+	result := r.db.Where("folder_id = ?", folderID).Order("id").Find(&documents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return documents, nil
+}
+
+// ListDocumentsByIDs returns the documents in scope among ids; missing ones are left out.
+func (r *Repository) ListDocumentsByIDs(ids []uint, scope repository.AccessScope) ([]entity.Document, error) {
+	var documents []entity.Document
+	if len(ids) == 0 {
+		return documents, nil
+	}
+	result := r.db.Scopes(documentAccess(scope)).Where("documents.id IN ?", ids).Order("id").Find(&documents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return documents, nil
+}
+
+// FindDocumentsByFileHash returns documents in scope whose file has the given
+// SHA-256, except the document excludeID.
+func (r *Repository) FindDocumentsByFileHash(hash string, excludeID uint, scope repository.AccessScope) ([]entity.Document, error) {
+	var documents []entity.Document
+	result := r.db.Scopes(documentAccess(scope)).
+		Where("file_hash = ? AND documents.id <> ?", hash, excludeID).
+		Order("documents.id").
+		Find(&documents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return documents, nil
+}
+
+// documentSortColumns maps list sort keys to columns.
+var documentSortColumns = map[string]string{
+	repository.DocumentSortCreatedAt:   "documents.created_at",
+	repository.DocumentSortTitle:       "documents.title",
+	repository.DocumentSortSheetsCount: "documents.sheets_count",
+	repository.DocumentSortID:          "documents.id",
+}
+
+// ListDocuments returns one page of documents in scope. Paging is keyset based on
+// (sort column, id), so pages stay stable while documents are added or removed.
+func (r *Repository) ListDocuments(filter repository.DocumentListFilter, scope repository.AccessScope) ([]entity.Document, error) {
+	column, ok := documentSortColumns[filter.SortBy]
+	if !ok {
+		column = documentSortColumns[repository.DocumentSortCreatedAt]
+	}
+
+	// Paging backwards walks the list in reverse order and flips the page afterwards
+	desc := filter.Desc
+	cursor := filter.After
+	if filter.Before != nil {
+		desc = !desc
+		cursor = filter.Before
+	}
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	query := r.db.Scopes(documentAccess(scope), documentListFilters(filter)).
+		Preload("Folder").Preload("DocumentType")
+	if cursor != nil {
+		query = query.Where("("+column+", documents.id) "+comparison+" (?, ?)", cursor.Value, cursor.ID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var documents []entity.Document
+	if err := query.Order(column + " " + direction + ", documents.id " + direction).Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	if filter.Before != nil {
+		for i, j := 0, len(documents)-1; i < j; i, j = i+1, j-1 {
+			documents[i], documents[j] = documents[j], documents[i]
+		}
+	}
+	return documents, nil
+}
+
+// CountDocuments returns how many documents in scope match the filter, ignoring
+// cursors and limit.
+func (r *Repository) CountDocuments(filter repository.DocumentListFilter, scope repository.AccessScope) (int64, error) {
+	var count int64
+	result := r.db.Model(&entity.Document{}).Scopes(documentAccess(scope), documentListFilters(filter)).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+func documentListFilters(filter repository.DocumentListFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.FolderID != nil {
+			db = db.Where("documents.folder_id = ?", *filter.FolderID)
+		}
+		if filter.Unfiled {
+			db = db.Where("documents.folder_id IS NULL")
+		}
+		if filter.DocumentTypeID != nil {
+			db = db.Where("documents.document_type_id = ?", *filter.DocumentTypeID)
+		}
+		if filter.From != nil {
+			db = db.Where("documents.created_at >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			db = db.Where("documents.created_at < ?", *filter.To)
+		}
+		if filter.MinSheets != nil {
+			db = db.Where("documents.sheets_count >= ?", *filter.MinSheets)
+		}
+		if filter.MaxSheets != nil {
+			db = db.Where("documents.sheets_count <= ?", *filter.MaxSheets)
+		}
+		return db
+	}
+}
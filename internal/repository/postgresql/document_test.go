@@ -0,0 +1,196 @@
+package postgresql_test
+
+import (
+	"sort"
+	"testing"
+
+	"folder-system/internal/config"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+	"folder-system/internal/service"
+)
+
+// createListDocuments files documents with repeated titles and sheet counts into a
+// new folder, so every sort key has ties that only the ID breaks. The documents are
+// returned as stored.
+func createListDocuments(t *testing.T, repo repository.Store, documents service.DocumentService, folder *entity.Folder) []entity.Document {
+	t.Helper()
+	admin := service.Actor{UserID: 1, Role: entity.RoleAdmin}
+	titles := []string{"delta", "alpha", "charlie", "alpha", "bravo", "delta", "alpha"}
+	sheets := []int{3, 1, 2, 3, 1, 2, 3}
+	ids := make([]uint, len(titles))
+	for i := range titles {
+		document, err := documents.CreateDocument(admin, titles[i], sheets[i], &folder.ID, 1, "", false)
+		if err != nil {
+			t.Fatalf("create document: %v", err)
+		}
+		ids[i] = document.ID
+	}
+	stored, err := repo.ListDocumentsByIDs(ids, repository.AccessScope{Unrestricted: true})
+	if err != nil {
+		t.Fatalf("reload documents: %v", err)
+	}
+	return stored
+}
+
+// sortDocuments orders documents the way the list does: by the sort key, then by ID.
+func sortDocuments(documents []entity.Document, sortBy string, desc bool) []uint {
+	sorted := append([]entity.Document(nil), documents...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if desc {
+			a, b = b, a
+		}
+		switch sortBy {
+		case repository.DocumentSortCreatedAt:
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+		case repository.DocumentSortTitle:
+			if a.Title != b.Title {
+				return a.Title < b.Title
+			}
+		case repository.DocumentSortSheetsCount:
+			if a.SheetsCount != b.SheetsCount {
+				return a.SheetsCount < b.SheetsCount
+			}
+		}
+		return a.ID < b.ID
+	})
+	ids := make([]uint, len(sorted))
+	for i := range sorted {
+		ids[i] = sorted[i].ID
+	}
+	return ids
+}
+
+func pageIDs(page *service.DocumentPage) []uint {
+	ids := make([]uint, len(page.Documents))
+	for i := range page.Documents {
+		ids[i] = page.Documents[i].ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestListDocumentsKeysetPaging walks the list forwards to the end and back again
+// for every sort key in both directions and checks the pages add up to the whole
+// list in order.
+func TestListDocumentsKeysetPaging(t *testing.T) {
+	repo := testRepository(t)
+	folder := createTestFolder(t, repo, 100)
+	documentService := service.NewDocumentService(repo, repo, repo, repo, repo, nil, config.DeduplicationConfig{})
+	admin := service.Actor{UserID: 1, Role: entity.RoleAdmin}
+	documents := createListDocuments(t, repo, documentService, folder)
+
+	const limit = 3
+	sortKeys := []string{
+		repository.DocumentSortCreatedAt,
+		repository.DocumentSortTitle,
+		repository.DocumentSortSheetsCount,
+		repository.DocumentSortID,
+	}
+	for _, sortBy := range sortKeys {
+		for _, desc := range []bool{false, true} {
+			want := sortDocuments(documents, sortBy, desc)
+			query := service.DocumentListQuery{FolderID: &folder.ID, SortBy: sortBy, Desc: desc, Limit: limit}
+
+			var forward []uint
+			var pages []*service.DocumentPage
+			for {
+				page, err := documentService.ListDocuments(admin, query)
+				if err != nil {
+					t.Fatalf("%s desc=%v: list: %v", sortBy, desc, err)
+				}
+				if page.Total != int64(len(documents)) {
+					t.Errorf("%s desc=%v: total = %d, want %d", sortBy, desc, page.Total, len(documents))
+				}
+				if len(pages) == 0 && page.PrevCursor != "" {
+					t.Errorf("%s desc=%v: first page has a previous cursor", sortBy, desc)
+				}
+				pages = append(pages, page)
+				forward = append(forward, pageIDs(page)...)
+				if page.NextCursor == "" {
+					break
+				}
+				if len(pages) > len(documents) {
+					t.Fatalf("%s desc=%v: paging forwards does not end", sortBy, desc)
+				}
+				query.Cursor = page.NextCursor
+			}
+			if !equalIDs(forward, want) {
+				t.Errorf("%s desc=%v: forwards = %v, want %v", sortBy, desc, forward, want)
+			}
+
+			// Walk back from the last page; each previous page must match the one seen going forwards
+			last := pages[len(pages)-1]
+			query.Cursor = last.PrevCursor
+			for i := len(pages) - 2; i >= 0; i-- {
+				if query.Cursor == "" {
+					t.Fatalf("%s desc=%v: page %d has no previous cursor", sortBy, desc, i+1)
+				}
+				page, err := documentService.ListDocuments(admin, query)
+				if err != nil {
+					t.Fatalf("%s desc=%v: list backwards: %v", sortBy, desc, err)
+				}
+				if got, want := pageIDs(page), pageIDs(pages[i]); !equalIDs(got, want) {
+					t.Errorf("%s desc=%v: backwards page %d = %v, want %v", sortBy, desc, i, got, want)
+				}
+				if page.NextCursor == "" {
+					t.Errorf("%s desc=%v: backwards page %d has no next cursor", sortBy, desc, i)
+				}
+				query.Cursor = page.PrevCursor
+			}
+			if query.Cursor != "" {
+				t.Errorf("%s desc=%v: first page reached backwards still has a previous cursor", sortBy, desc)
+			}
+		}
+	}
+}
+
+// TestListDocumentsStableUnderInserts adds a document that sorts onto the first page
+// between two page requests; the second page must neither repeat nor skip documents.
+func TestListDocumentsStableUnderInserts(t *testing.T) {
+	repo := testRepository(t)
+	folder := createTestFolder(t, repo, 100)
+	documentService := service.NewDocumentService(repo, repo, repo, repo, repo, nil, config.DeduplicationConfig{})
+	admin := service.Actor{UserID: 1, Role: entity.RoleAdmin}
+	documents := createListDocuments(t, repo, documentService, folder)
+	want := sortDocuments(documents, repository.DocumentSortTitle, false)
+
+	query := service.DocumentListQuery{FolderID: &folder.ID, SortBy: repository.DocumentSortTitle, Limit: 3}
+	first, err := documentService.ListDocuments(admin, query)
+	if err != nil {
+		t.Fatalf("list first page: %v", err)
+	}
+	if got := pageIDs(first); !equalIDs(got, want[:3]) {
+		t.Fatalf("first page = %v, want %v", got, want[:3])
+	}
+
+	if _, err := documentService.CreateDocument(admin, "aardvark", 1, &folder.ID, 1, "", false); err != nil {
+		t.Fatalf("create document: %v", err)
+	}
+
+	query.Cursor = first.NextCursor
+	second, err := documentService.ListDocuments(admin, query)
+	if err != nil {
+		t.Fatalf("list second page: %v", err)
+	}
+	if got := pageIDs(second); !equalIDs(got, want[3:6]) {
+		t.Errorf("second page = %v, want %v", got, want[3:6])
+	}
+	if second.Total != int64(len(documents)+1) {
+		t.Errorf("total = %d, want %d", second.Total, len(documents)+1)
+	}
+}
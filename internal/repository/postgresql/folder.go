@@ -1,34 +1,192 @@
 package postgresql
 
-import "folder-system/internal/entity"
+import (
+	"errors"
+
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
 func (r *Repository) CreateFolder(folder *entity.Folder) error {
 	return r.db.Create(folder).Error
 }
 
-func (r *Repository) GetFolderByID(id uint) (*entity.Folder, error) {
+func (r *Repository) GetFolderByID(id uint, scope repository.AccessScope) (*entity.Folder, error) {
 	var folder entity.Folder
-	result := r.db.Preload("FolderType").First(&folder, id)
+	result := r.db.Scopes(folderAccess(scope)).Preload("FolderType").First(&folder, id)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &folder, nil
 }
 
+// LockFolderByID loads a folder with SELECT ... FOR UPDATE. It must be called
+// inside a transaction; the row stays locked until the transaction ends.
+func (r *Repository) LockFolderByID(id uint, scope repository.AccessScope) (*entity.Folder, error) {
+	var folder entity.Folder
+	result := r.db.Scopes(folderAccess(scope)).Clauses(clause.Locking{Strength: "UPDATE"}).First(&folder, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &folder, nil
+}
+
+// folderSortColumns maps list sort keys to SQL expressions. Folders without
+// capacity count as full.
+var folderSortColumns = map[string]string{
+	repository.FolderSortID:         "folders.id",
+	repository.FolderSortName:       "folders.name",
+	repository.FolderSortFill:       "COALESCE(folders.used_sheets::float / NULLIF(folders.total_sheets, 0), 1)",
+	repository.FolderSortFreeSheets: "(folders.total_sheets - folders.used_sheets)",
+}
+
+func (r *Repository) ListFolders(filter repository.FolderListFilter, scope repository.AccessScope) ([]entity.Folder, error) {
+	query := r.db.Scopes(folderAccess(scope)).Preload("FolderType")
+	if filter.FolderTypeID != nil {
+		query = query.Where("folders.folder_type_id = ?", *filter.FolderTypeID)
+	}
+	if filter.MinFreeSheets != nil {
+		query = query.Where("(folders.total_sheets - folders.used_sheets) >= ?", *filter.MinFreeSheets)
+	}
+
+	column, ok := folderSortColumns[filter.SortBy]
+	if !ok {
+		column = folderSortColumns[repository.FolderSortID]
+	}
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+
+	var folders []entity.Folder
+	result := query.Order(column + " " + direction + ", folders.id").Find(&folders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return folders, nil
+}
+
+// CountDocumentsByFolder returns the number of documents filed in each of the
+// given folders. Empty folders are missing from the map.
+func (r *Repository) CountDocumentsByFolder(folderIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(folderIDs))
+	if len(folderIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		FolderID uint
+		Count    int64
+	}
+	result := r.db.Model(&entity.Document{}).
+		Select("folder_id, COUNT(*) AS count").
+		Where("folder_id IN ?", folderIDs).
+		Group("folder_id").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	for _, row := range rows {
+		counts[row.FolderID] = row.Count
+	}
+	return counts, nil
+}
+
 func (r *Repository) UpdateFolder(folder *entity.Folder) error {
-	return r.db.Save(folder).Error
+	return r.db.Omit(clause.Associations).Save(folder).Error
 }
 
-func (r *Repository) FindFolderByTypeAndCapacity(folderTypeID uint, sheetsRequired int) (*entity.Folder, error) {
-	var folder entity.Folder
-	// Find the first folder of the given type that has enough free space.
+func (r *Repository) DeleteFolder(id uint) error {
+	if err := r.db.Where("folder_id = ?", id).Delete(&entity.FolderGrant{}).Error; err != nil {
+		return err
+	}
+	return r.db.Delete(&entity.Folder{}, id).Error
+}
+
+func (r *Repository) CountDocumentsInFolder(folderID uint) (int64, error) {
+	var count int64
+	result := r.db.Model(&entity.Document{}).Where("folder_id = ?", folderID).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+func (r *Repository) UnfileDocumentsInFolder(folderID uint) error {
+	return r.db.Model(&entity.Document{}).
+		Where("folder_id = ?", folderID).
+		Update("folder_id", nil).Error
+}
+
+func (r *Repository) DeleteDocumentsInFolder(folderID uint) error {
+	return r.db.Where("folder_id = ?", folderID).Delete(&entity.Document{}).Error
+}
+
+// FindFoldersByTypeAndCapacity returns all folders of the given type that have
+// enough free space and are on the shelf, ordered by ID; checked-out folders
+// cannot be filed into. Choosing among them is up to the caller.
+// A non-nil containerIDs limits the result to folders kept in those containers.
+func (r *Repository) FindFoldersByTypeAndCapacity(folderTypeID uint, sheetsRequired int, containerIDs []uint, scope repository.AccessScope) ([]entity.Folder, error) {
+	var folders []entity.Folder
 	// (total_sheets - used_sheets) >= sheetsRequired
-	result := r.db.
+	query := r.db.Scopes(folderAccess(scope)).Preload("FolderType").
 		Where("folder_type_id = ? AND (total_sheets - used_sheets) >= ?", folderTypeID, sheetsRequired).
-		First(&folder)
+		Where("NOT EXISTS (SELECT 1 FROM loans WHERE loans.folder_id = folders.id AND loans.returned_at IS NULL)")
+	if containerIDs != nil {
+		query = query.Where("container_id IN ?", containerIDs)
+	}
+	result := query.Order("id").Find(&folders)
 
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	return &folder, nil
+	return folders, nil
+}
+
+// ReserveSheets atomically takes sheets from the folder's free space. The check and
+// the increment happen in one conditional UPDATE, so concurrent reservations can
+// never push used_sheets past total_sheets.
+func (r *Repository) ReserveSheets(folderID uint, sheets int) error {
+	result := r.db.Model(&entity.Folder{}).
+		Where("id = ? AND (total_sheets - used_sheets) >= ?", folderID, sheets).
+		Update("used_sheets", gorm.Expr("used_sheets + ?", sheets))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.missingOr(folderID, repository.ErrNotEnoughSpace)
+	}
+	return nil
+}
+
+// ReleaseSheets atomically gives sheets back to the folder's free space. Like
+// ReserveSheets, it refuses to push used_sheets below zero.
+func (r *Repository) ReleaseSheets(folderID uint, sheets int) error {
+	result := r.db.Model(&entity.Folder{}).
+		Where("id = ? AND used_sheets >= ?", folderID, sheets).
+		Update("used_sheets", gorm.Expr("used_sheets - ?", sheets))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.missingOr(folderID, repository.ErrNotEnoughUsed)
+	}
+	return nil
+}
+
+// missingOr explains a conditional sheet update that matched no row: either the
+// folder does not exist, or its condition failed with conditionErr.
+func (r *Repository) missingOr(folderID uint, conditionErr error) error {
+	var folder entity.Folder
+	err := r.db.Select("id").First(&folder, folderID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return conditionErr
 }
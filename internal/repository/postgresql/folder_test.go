@@ -0,0 +1,124 @@
+package postgresql_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"folder-system/internal/config"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+	"folder-system/internal/service"
+)
+
+// TestReserveSheetsConcurrent files documents into one folder from many goroutines
+// and checks that the folder is never overfilled and that its usage matches the
+// documents actually filed.
+func TestReserveSheetsConcurrent(t *testing.T) {
+	repo := testRepository(t)
+	folder := createTestFolder(t, repo, 100)
+
+	documentService := service.NewDocumentService(repo, repo, repo, repo, repo, nil, config.DeduplicationConfig{})
+	admin := service.Actor{UserID: 1, Role: entity.RoleAdmin}
+
+	const workers = 50
+	const sheetsEach = 7 // 50 * 7 = 350 sheets for 100 of capacity
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	filed, rejected := 0, 0
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := documentService.CreateDocument(admin, "concurrent", sheetsEach, &folder.ID, 1, "", false)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				filed++
+			case errors.Is(err, service.ErrNotEnoughSpace):
+				rejected++
+			default:
+				t.Errorf("create document: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var got entity.Folder
+	if err := repo.DB().First(&got, folder.ID).Error; err != nil {
+		t.Fatalf("reload folder: %v", err)
+	}
+	var filedSheets int
+	err := repo.DB().Model(&entity.Document{}).
+		Where("folder_id = ?", folder.ID).
+		Select("COALESCE(SUM(sheets_count), 0)").
+		Scan(&filedSheets).Error
+	if err != nil {
+		t.Fatalf("sum sheets: %v", err)
+	}
+
+	if got.UsedSheets > got.TotalSheets {
+		t.Errorf("used_sheets %d exceeds total_sheets %d", got.UsedSheets, got.TotalSheets)
+	}
+	if got.UsedSheets != filedSheets {
+		t.Errorf("used_sheets %d, filed documents hold %d sheets", got.UsedSheets, filedSheets)
+	}
+	if want := got.TotalSheets / sheetsEach; filed != want {
+		t.Errorf("filed %d documents, want %d (rejected %d)", filed, want, rejected)
+	}
+}
+
+// TestReleaseSheetsConcurrent reserves and releases in parallel and expects the
+// folder to end up empty.
+func TestReleaseSheetsConcurrent(t *testing.T) {
+	repo := testRepository(t)
+	folder := createTestFolder(t, repo, 480)
+
+	const workers = 40
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := repo.ReserveSheets(folder.ID, 10); err != nil {
+				t.Errorf("reserve: %v", err)
+				return
+			}
+			if err := repo.ReleaseSheets(folder.ID, 10); err != nil {
+				t.Errorf("release: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var got entity.Folder
+	if err := repo.DB().First(&got, folder.ID).Error; err != nil {
+		t.Fatalf("reload folder: %v", err)
+	}
+	if got.UsedSheets != 0 {
+		t.Errorf("used_sheets = %d after releasing everything, want 0", got.UsedSheets)
+	}
+}
+
+// TestReleaseSheetsUnderflow expects releasing more sheets than a folder holds to
+// fail and leave used_sheets untouched.
+func TestReleaseSheetsUnderflow(t *testing.T) {
+	repo := testRepository(t)
+	folder := createTestFolder(t, repo, 480)
+
+	if err := repo.ReserveSheets(folder.ID, 5); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if err := repo.ReleaseSheets(folder.ID, 10); !errors.Is(err, repository.ErrNotEnoughUsed) {
+		t.Fatalf("release = %v, want ErrNotEnoughUsed", err)
+	}
+
+	var got entity.Folder
+	if err := repo.DB().First(&got, folder.ID).Error; err != nil {
+		t.Fatalf("reload folder: %v", err)
+	}
+	if got.UsedSheets != 5 {
+		t.Errorf("used_sheets = %d, want 5", got.UsedSheets)
+	}
+}
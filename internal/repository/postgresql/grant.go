@@ -0,0 +1,69 @@
+package postgresql
+
+import (
+	"errors"
+
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+func (r *Repository) CreateFolderGrant(grant *entity.FolderGrant) error {
+	return r.db.Create(grant).Error
+}
+
+func (r *Repository) GetFolderGrant(folderID, grantID uint) (*entity.FolderGrant, error) {
+	var grant entity.FolderGrant
+	result := r.db.Where("folder_id = ?", folderID).First(&grant, grantID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &grant, nil
+}
+
+// FindFolderGrant returns the live grant of a permission on a folder to the given
+// user or group.
+func (r *Repository) FindFolderGrant(folderID uint, userID, groupID *uint, permission string) (*entity.FolderGrant, error) {
+	query := r.db.Where("folder_id = ? AND permission = ?", folderID, permission)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	} else {
+		query = query.Where("user_id IS NULL")
+	}
+	if groupID != nil {
+		query = query.Where("group_id = ?", *groupID)
+	} else {
+		query = query.Where("group_id IS NULL")
+	}
+
+	var grant entity.FolderGrant
+	result := query.First(&grant)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &grant, nil
+}
+
+func (r *Repository) ListFolderGrants(folderID uint) ([]entity.FolderGrant, error) {
+	var grants []entity.FolderGrant
+	result := r.db.Where("folder_id = ?", folderID).Order("id").Find(&grants)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return grants, nil
+}
+
+func (r *Repository) DeleteFolderGrant(folderID, grantID uint) error {
+	result := r.db.Where("folder_id = ?", folderID).Delete(&entity.FolderGrant{}, grantID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
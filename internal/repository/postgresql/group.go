@@ -0,0 +1,41 @@
+package postgresql
+
+import "folder-system/internal/entity"
+
+func (r *Repository) CreateGroup(group *entity.Group) error {
+	return r.db.Create(group).Error
+}
+
+func (r *Repository) GetGroupByID(id uint) (*entity.Group, error) {
+	var group entity.Group
+	result := r.db.Preload("Members").First(&group, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &group, nil
+}
+
+// ListGroupsForUser returns the groups the user created or is a member of.
+func (r *Repository) ListGroupsForUser(userID uint) ([]entity.Group, error) {
+	var groups []entity.Group
+	members := r.db.Model(&entity.GroupMember{}).Select("group_id").Where("user_id = ?", userID)
+	result := r.db.Preload("Members").
+		Where("created_by = ? OR id IN (?)", userID, members).
+		Order("id").
+		Find(&groups)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return groups, nil
+}
+
+func (r *Repository) AddGroupMember(member *entity.GroupMember) error {
+	return r.db.Create(member).Error
+}
+
+func (r *Repository) RemoveGroupMember(groupID, userID uint) error {
+	// Hard delete so the user can be added back later
+	return r.db.Unscoped().
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Delete(&entity.GroupMember{}).Error
+}
@@ -0,0 +1,81 @@
+package postgresql
+
+import (
+	"folder-system/internal/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *Repository) CreateInventorySession(session *entity.InventorySession) error {
+	return r.db.Create(session).Error
+}
+
+// LockInventorySession loads a session with SELECT ... FOR UPDATE. It must be
+// called inside a transaction.
+func (r *Repository) LockInventorySession(id uint) (*entity.InventorySession, error) {
+	var session entity.InventorySession
+	result := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &session, nil
+}
+
+func (r *Repository) UpdateInventorySession(session *entity.InventorySession) error {
+	return r.db.Save(session).Error
+}
+
+// RecordInventoryFolder replaces what was recorded for the folder in the session.
+// A document can only be in one place, so scans of the same documents recorded
+// earlier in other folders are dropped as well.
+func (r *Repository) RecordInventoryFolder(folder *entity.InventoryFolder, items []entity.InventoryItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "session_id"}, {Name: "folder_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"recorded_by", "recorded_at"}),
+		}).Create(folder).Error
+		if err != nil {
+			return err
+		}
+
+		var documentIDs []uint
+		for _, item := range items {
+			if item.DocumentID != nil {
+				documentIDs = append(documentIDs, *item.DocumentID)
+			}
+		}
+		query := tx.Where("session_id = ?", folder.SessionID)
+		if len(documentIDs) > 0 {
+			query = query.Where("folder_id = ? OR document_id IN ?", folder.FolderID, documentIDs)
+		} else {
+			query = query.Where("folder_id = ?", folder.FolderID)
+		}
+		if err := query.Delete(&entity.InventoryItem{}).Error; err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Create(&items).Error
+	})
+}
+
+func (r *Repository) ListInventoryFolders(sessionID uint) ([]entity.InventoryFolder, error) {
+	var folders []entity.InventoryFolder
+	result := r.db.Where("session_id = ?", sessionID).Order("folder_id").Find(&folders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return folders, nil
+}
+
+func (r *Repository) ListInventoryItems(sessionID uint) ([]entity.InventoryItem, error) {
+	var items []entity.InventoryItem
+	result := r.db.Where("session_id = ?", sessionID).Order("id").Find(&items)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return items, nil
+}
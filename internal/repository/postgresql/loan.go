@@ -0,0 +1,93 @@
+package postgresql
+
+import (
+	"errors"
+
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *Repository) CreateLoan(loan *entity.Loan) error {
+	return r.db.Create(loan).Error
+}
+
+// LockLoan loads a loan with SELECT ... FOR UPDATE. It must be called inside a transaction.
+func (r *Repository) LockLoan(id uint) (*entity.Loan, error) {
+	var loan entity.Loan
+	result := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&loan, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &loan, nil
+}
+
+func (r *Repository) UpdateLoan(loan *entity.Loan) error {
+	return r.db.Save(loan).Error
+}
+
+// ActiveFolderLoan returns the loan the folder is currently checked out on, or
+// repository.ErrNotFound if it is on the shelf.
+func (r *Repository) ActiveFolderLoan(folderID uint) (*entity.Loan, error) {
+	return r.activeLoan("folder_id = ?", folderID)
+}
+
+// ActiveDocumentLoan returns the loan the document is currently checked out on, or
+// repository.ErrNotFound if it is in its folder.
+func (r *Repository) ActiveDocumentLoan(documentID uint) (*entity.Loan, error) {
+	return r.activeLoan("document_id = ?", documentID)
+}
+
+// CountActiveDocumentLoansInFolder returns how many documents filed in the folder
+// are currently checked out on their own.
+func (r *Repository) CountActiveDocumentLoansInFolder(folderID uint) (int64, error) {
+	var count int64
+	result := r.db.Model(&entity.Loan{}).
+		Joins("JOIN documents ON documents.id = loans.document_id AND documents.deleted_at IS NULL").
+		Where("documents.folder_id = ? AND loans.returned_at IS NULL", folderID).
+		Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+func (r *Repository) activeLoan(condition string, id uint) (*entity.Loan, error) {
+	var loan entity.Loan
+	result := r.db.Where(condition, id).Where("returned_at IS NULL").First(&loan)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &loan, nil
+}
+
+// ListLoans returns loans matching the filter, the ones due first first.
+func (r *Repository) ListLoans(filter repository.LoanFilter) ([]entity.Loan, error) {
+	query := r.db.Model(&entity.Loan{})
+	if filter.ActiveOnly || filter.OverdueAt != nil {
+		query = query.Where("returned_at IS NULL")
+	}
+	if filter.OverdueAt != nil {
+		query = query.Where("due_at < ?", *filter.OverdueAt)
+	}
+	if filter.FolderID != nil {
+		query = query.Where("folder_id = ?", *filter.FolderID)
+	}
+	if filter.DocumentID != nil {
+		query = query.Where("document_id = ?", *filter.DocumentID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("borrower_id = ? OR lent_by = ?", *filter.UserID, *filter.UserID)
+	}
+
+	var loans []entity.Loan
+	if err := query.Order("due_at, id").Find(&loans).Error; err != nil {
+		return nil, err
+	}
+	return loans, nil
+}
@@ -0,0 +1,50 @@
+package postgresql_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"folder-system/internal/config"
+	"folder-system/internal/entity"
+	"folder-system/internal/service"
+)
+
+// TestCheckOutFolderWithDocumentOnLoan checks that a folder cannot be lent while
+// one of its documents is out, and a document not while its folder is.
+func TestCheckOutFolderWithDocumentOnLoan(t *testing.T) {
+	repo := testRepository(t)
+	folder := createTestFolder(t, repo, 10)
+	borrower := &entity.User{Email: fmt.Sprintf("borrower-%d@example.com", time.Now().UnixNano()), Password: "x", Role: entity.RoleClerk}
+	if err := repo.CreateUser(borrower); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	documentService := service.NewDocumentService(repo, repo, repo, repo, repo, nil, config.DeduplicationConfig{})
+	loanService := service.NewLoanService(repo, repo)
+	admin := service.Actor{UserID: 1, Role: entity.RoleAdmin}
+	due := time.Now().Add(24 * time.Hour)
+
+	document, err := documentService.CreateDocument(admin, "on loan", 1, &folder.ID, 1, "", false)
+	if err != nil {
+		t.Fatalf("create document: %v", err)
+	}
+	documentLoan, err := loanService.CheckOut(admin, nil, &document.ID, borrower.ID, due, "")
+	if err != nil {
+		t.Fatalf("check out document: %v", err)
+	}
+	if _, err := loanService.CheckOut(admin, &folder.ID, nil, borrower.ID, due, ""); !errors.Is(err, service.ErrDocumentsOnLoan) {
+		t.Fatalf("check out folder with a document out: err = %v, want ErrDocumentsOnLoan", err)
+	}
+
+	if _, err := loanService.CheckIn(admin, documentLoan.ID); err != nil {
+		t.Fatalf("check in document: %v", err)
+	}
+	if _, err := loanService.CheckOut(admin, &folder.ID, nil, borrower.ID, due, ""); err != nil {
+		t.Fatalf("check out folder: %v", err)
+	}
+	if _, err := loanService.CheckOut(admin, nil, &document.ID, borrower.ID, due, ""); !errors.Is(err, service.ErrAlreadyCheckedOut) {
+		t.Errorf("check out document of a lent folder: err = %v, want ErrAlreadyCheckedOut", err)
+	}
+}
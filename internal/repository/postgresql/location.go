@@ -0,0 +1,37 @@
+package postgresql
+
+import (
+	"errors"
+
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+func (r *Repository) CreateFolderLocation(location *entity.FolderLocation) error {
+	return r.db.Create(location).Error
+}
+
+// CurrentFolderLocation returns the newest location entry of the folder, or
+// repository.ErrNotFound if the folder was never placed anywhere.
+func (r *Repository) CurrentFolderLocation(folderID uint) (*entity.FolderLocation, error) {
+	var location entity.FolderLocation
+	result := r.db.Where("folder_id = ?", folderID).Order("id DESC").First(&location)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &location, nil
+}
+
+// ListFolderLocations returns the location history of the folder, newest first.
+func (r *Repository) ListFolderLocations(folderID uint) ([]entity.FolderLocation, error) {
+	var locations []entity.FolderLocation
+	if err := r.db.Where("folder_id = ?", folderID).Order("id DESC").Find(&locations).Error; err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
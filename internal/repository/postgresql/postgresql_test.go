@@ -0,0 +1,40 @@
+package postgresql_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"folder-system/internal/entity"
+	"folder-system/internal/repository/postgresql"
+)
+
+// testRepository connects to the database in TEST_DATABASE_DSN. Tests that need
+// Postgres are skipped when it is not set; the database is migrated on connect.
+func testRepository(t *testing.T) *postgresql.Repository {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	repo, err := postgresql.NewRepository(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	return repo
+}
+
+// createTestFolder creates a folder of the given capacity that is unique to the test run.
+func createTestFolder(t *testing.T, repo *postgresql.Repository, totalSheets int) *entity.Folder {
+	t.Helper()
+	folder := &entity.Folder{
+		Name:         fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano()),
+		TotalSheets:  totalSheets,
+		FolderTypeID: 1,
+	}
+	if err := repo.CreateFolder(folder); err != nil {
+		t.Fatalf("create folder: %v", err)
+	}
+	return folder
+}
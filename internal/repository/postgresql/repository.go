@@ -3,8 +3,10 @@ package postgresql
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"folder-system/internal/entity"
+	"folder-system/internal/repository"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -23,11 +25,27 @@ func NewRepository(dsn string) (*Repository, error) {
 	// Auto Migrate - создает таблицы если их нет
 	err = db.AutoMigrate(
 		&entity.User{},
+		&entity.RefreshToken{},
+		&entity.Container{},
 		&entity.Folder{},
+		&entity.FolderLocation{},
+		&entity.Loan{},
+		&entity.ScanSession{},
+		&entity.InventorySession{},
+		&entity.InventoryFolder{},
+		&entity.InventoryItem{},
 		&entity.Document{},
+		&entity.DocumentVersion{},
+		&entity.Blob{},
+		&entity.Upload{},
 		&entity.DocumentType{},
 		&entity.FolderType{},
 		&entity.FolderTypeAssignment{},
+		&entity.Group{},
+		&entity.GroupMember{},
+		&entity.FolderGrant{},
+		&entity.AuditRecord{},
+		&appliedMigration{},
 	)
 	if err != nil {
 		log.Printf("Warning: Auto migration completed with errors: %v", err)
@@ -35,6 +53,14 @@ func NewRepository(dsn string) (*Repository, error) {
 		log.Println("Database tables migrated successfully")
 	}
 
+	if err := protectAuditTrail(db); err != nil {
+		return nil, fmt.Errorf("failed to protect audit trail: %w", err)
+	}
+
+	if err := createSearchIndex(db); err != nil {
+		return nil, fmt.Errorf("failed to create search index: %w", err)
+	}
+
 	// Создаем начальные данные если таблицы пустые
 	if err := createInitialData(db); err != nil {
 		return nil, fmt.Errorf("failed to create initial data: %w", err)
@@ -43,6 +69,21 @@ func NewRepository(dsn string) (*Repository, error) {
 	return &Repository{db: db}, nil
 }
 
+// AssignOrphanedRecords hands folders and documents created before ownership was
+// tracked (created_by = 0) to the given user and returns how many were assigned.
+// Until then they are visible to admins only.
+func (r *Repository) AssignOrphanedRecords(ownerID uint) (int64, error) {
+	var assigned int64
+	for _, model := range []interface{}{&entity.Folder{}, &entity.Document{}} {
+		result := r.db.Model(model).Where("created_by = 0").Update("created_by", ownerID)
+		if result.Error != nil {
+			return assigned, result.Error
+		}
+		assigned += result.RowsAffected
+	}
+	return assigned, nil
+}
+
 func createInitialData(db *gorm.DB) error {
 	// Создаем основные типы документов если их нет
 	var docTypeCount int64
@@ -51,19 +92,27 @@ func createInitialData(db *gorm.DB) error {
 	}
 
 	if docTypeCount == 0 {
+		// Contracts and invoices are kept 5 years (contracts after they expire), reports 1 year
 		documentTypes := []entity.DocumentType{
-			{Name: "Contract"},     // ID = 1
-			{Name: "Report"},       // ID = 2
-			{Name: "Invoice"},      // ID = 3
+			{Name: "Contract", RetentionMonths: 60, RetentionTrigger: entity.RetentionTriggerExpiry}, // ID = 1
+			{Name: "Report", RetentionMonths: 12, RetentionTrigger: entity.RetentionTriggerCreated},  // ID = 2
+			{Name: "Invoice", RetentionMonths: 60, RetentionTrigger: entity.RetentionTriggerCreated}, // ID = 3
 			{Name: "Presentation"}, // ID = 4
 		}
 		if err := db.Create(&documentTypes).Error; err != nil {
 			return err
 		}
 		log.Println("Created default document types")
+		// The seeded types already have their rules
+		if err := markMigrationApplied(db, retentionDefaultsMigration); err != nil {
+			return err
+		}
 	} else {
 		log.Println("Document types already exist, skipping creation")
 	}
+	if err := runOnce(db, retentionDefaultsMigration, backfillRetention); err != nil {
+		return err
+	}
 
 	// Создаем основные типы папок если их нет
 	var folderTypeCount int64
@@ -110,6 +159,111 @@ func createInitialData(db *gorm.DB) error {
 	return nil
 }
 
+// appliedMigration records a one-off data migration that has run, so that it is
+// not repeated on the next startup.
+type appliedMigration struct {
+	Name      string `gorm:"primaryKey"`
+	AppliedAt time.Time
+}
+
+const retentionDefaultsMigration = "retention-defaults"
+
+// runOnce runs fn and records it under name in one transaction, unless a migration
+// of that name has been applied before.
+func runOnce(db *gorm.DB, name string, fn func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&appliedMigration{}).Where("name = ?", name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return markMigrationApplied(tx, name)
+	})
+}
+
+func markMigrationApplied(db *gorm.DB, name string) error {
+	return db.Create(&appliedMigration{Name: name, AppliedAt: time.Now()}).Error
+}
+
+// backfillRetention gives the default document types of a deployment created before
+// retention rules existed the same rules a fresh one is seeded with. It runs once:
+// afterwards retention_months = 0 is a deliberate "keep forever" and must stay.
+func backfillRetention(db *gorm.DB) error {
+	defaults := []entity.DocumentType{
+		{Name: "Contract", RetentionMonths: 60, RetentionTrigger: entity.RetentionTriggerExpiry},
+		{Name: "Report", RetentionMonths: 12, RetentionTrigger: entity.RetentionTriggerCreated},
+		{Name: "Invoice", RetentionMonths: 60, RetentionTrigger: entity.RetentionTriggerCreated},
+	}
+	for _, def := range defaults {
+		result := db.Model(&entity.DocumentType{}).
+			Where("name = ? AND retention_months = 0", def.Name).
+			Updates(map[string]interface{}{
+				"retention_months":  def.RetentionMonths,
+				"retention_trigger": def.RetentionTrigger,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Set default retention for document type %s", def.Name)
+		}
+	}
+	return nil
+}
+
+// Transaction runs fn in a database transaction. Nested calls use savepoints.
+func (r *Repository) Transaction(fn func(tx repository.Store) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&Repository{db: tx})
+	})
+}
+
 func (r *Repository) DB() *gorm.DB {
 	return r.db
 }
+
+// grantedFolders is a subquery of folder IDs shared with the scope's user, directly or
+// through a group, with at least the scope's permission.
+func grantedFolders(db *gorm.DB, scope repository.AccessScope) *gorm.DB {
+	permission := scope.Permission
+	if permission == "" {
+		permission = entity.PermissionRead
+	}
+	groups := db.Session(&gorm.Session{NewDB: true}).Model(&entity.GroupMember{}).
+		Select("group_id").
+		Where("user_id = ?", scope.UserID)
+	return db.Session(&gorm.Session{NewDB: true}).Model(&entity.FolderGrant{}).
+		Select("folder_id").
+		Where("permission IN ?", entity.PermissionsAtLeast(permission)).
+		Where("user_id = ? OR group_id IN (?)", scope.UserID, groups)
+}
+
+// folderAccess limits a query on folders to the ones the scope's user owns or was granted.
+func folderAccess(scope repository.AccessScope) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if scope.Unrestricted {
+			return db
+		}
+		return db.Where("folders.created_by = ? OR folders.id IN (?)", scope.UserID, grantedFolders(db, scope))
+	}
+}
+
+// documentAccess limits a query on documents to the ones the scope's user owns or that
+// are filed in a folder they own or that was granted to them.
+func documentAccess(scope repository.AccessScope) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if scope.Unrestricted {
+			return db
+		}
+		ownedFolders := db.Session(&gorm.Session{NewDB: true}).Model(&entity.Folder{}).
+			Select("id").
+			Where("created_by = ?", scope.UserID)
+		return db.Where("documents.created_by = ? OR documents.folder_id IN (?) OR documents.folder_id IN (?)",
+			scope.UserID, ownedFolders, grantedFolders(db, scope))
+	}
+}
@@ -0,0 +1,74 @@
+package postgresql
+
+import (
+	"time"
+
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+)
+
+// disposalDateSQL is when a document's retention period ends under the rule of its
+// type. It is NULL for documents that are kept: types without a retention period
+// and expiry-based rules on documents without an expiry date. Queries using it
+// must join document_types.
+const disposalDateSQL = `CASE
+	WHEN document_types.retention_months <= 0 THEN NULL
+	WHEN document_types.retention_trigger = 'expiry' THEN documents.expires_at + make_interval(months => document_types.retention_months)
+	ELSE documents.created_at + make_interval(months => document_types.retention_months)
+END`
+
+const joinDocumentTypes = "JOIN document_types ON document_types.id = documents.document_type_id"
+
+// DocumentDisposalDate returns when the document may be destroyed, or nil if it is kept.
+func (r *Repository) DocumentDisposalDate(id uint) (*time.Time, error) {
+	var row struct {
+		DisposalDate *time.Time
+	}
+	result := r.db.Model(&entity.Document{}).
+		Joins(joinDocumentTypes).
+		Select(disposalDateSQL+" AS disposal_date").
+		Where("documents.id = ?", id).
+		Scan(&row)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return row.DisposalDate, nil
+}
+
+// ListDisposableDocuments returns the documents whose retention period ended by
+// at, with DisposalDate filled in, the longest overdue first.
+func (r *Repository) ListDisposableDocuments(at time.Time) ([]entity.Document, error) {
+	var documents []entity.Document
+	result := r.db.Model(&entity.Document{}).
+		Joins(joinDocumentTypes).
+		Select("documents.*, "+disposalDateSQL+" AS disposal_date").
+		Where("("+disposalDateSQL+") <= ?", at).
+		Order("disposal_date, documents.id").
+		Find(&documents)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return documents, nil
+}
+
+// ListDisposableFolders returns the non-empty folders all of whose documents may
+// be destroyed by at.
+func (r *Repository) ListDisposableFolders(at time.Time) ([]repository.FolderDisposal, error) {
+	documents := r.db.Model(&entity.Document{}).
+		Joins(joinDocumentTypes).
+		Select("documents.folder_id, " + disposalDateSQL + " AS disposal_date").
+		Where("documents.folder_id IS NOT NULL")
+
+	var folders []repository.FolderDisposal
+	result := r.db.Table("(?) AS d", documents).
+		Joins("JOIN folders ON folders.id = d.folder_id AND folders.deleted_at IS NULL").
+		Select("d.folder_id, MAX(d.disposal_date) AS disposal_date, COUNT(*) AS document_count").
+		Group("d.folder_id").
+		Having("bool_and(d.disposal_date IS NOT NULL AND d.disposal_date <= ?)", at).
+		Order("disposal_date, d.folder_id").
+		Scan(&folders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return folders, nil
+}
@@ -0,0 +1,28 @@
+package postgresql
+
+import (
+	"folder-system/internal/entity"
+
+	"gorm.io/gorm/clause"
+)
+
+func (r *Repository) CreateScanSession(session *entity.ScanSession) error {
+	return r.db.Create(session).Error
+}
+
+// LockScanSession loads a session with SELECT ... FOR UPDATE so the scans of one
+// session are handled in order. It must be called inside a transaction.
+func (r *Repository) LockScanSession(id, userID uint) (*entity.ScanSession, error) {
+	var session entity.ScanSession
+	result := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND created_by = ?", id, userID).
+		First(&session)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &session, nil
+}
+
+func (r *Repository) UpdateScanSession(session *entity.ScanSession) error {
+	return r.db.Save(session).Error
+}
@@ -0,0 +1,225 @@
+package postgresql
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// documentSearchSQL adds a generated tsvector over title and file name. Titles are
+// a mix of Russian and English, so they are indexed with both configurations.
+const documentSearchSQL = `
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (
+		setweight(to_tsvector('russian', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(file_name, '')), 'B')
+	) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_documents_search_vector ON documents USING GIN (search_vector);
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_documents_title_trgm ON documents USING GIN (title gin_trgm_ops);
+`
+
+// searchQuery parses the user's query with both configurations used by the index.
+// It takes the query text twice.
+const searchQuery = "(websearch_to_tsquery('russian', ?) || websearch_to_tsquery('english', ?))"
+
+// searchHeadlineOptions highlights matches in the whole title, which is short.
+// The russian configuration also stems Latin words with the English stemmer.
+// Matches are marked with private-use characters rather than tags, because
+// ts_headline does not escape the title; headlineHTML turns them into <b></b>.
+const searchHeadlineOptions = "StartSel=\uE000, StopSel=\uE001, HighlightAll=true"
+
+const (
+	headlineStart = "\uE000"
+	headlineStop  = "\uE001"
+)
+
+// headlineHTML escapes a title marked up by ts_headline and wraps the matches in
+// <b></b>. Marker characters typed into a title are dropped, not turned into tags.
+func headlineHTML(marked string, highlighted bool) string {
+	if !highlighted {
+		marked = strings.NewReplacer(headlineStart, "", headlineStop, "").Replace(marked)
+	}
+	escaped := html.EscapeString(marked)
+	return strings.NewReplacer(headlineStart, "<b>", headlineStop, "</b>").Replace(escaped)
+}
+
+// fuzzyThreshold is the minimum trigram word similarity of a fuzzy match. It is lower
+// than the pg_trgm default of 0.6 so that one or two typos in a name still match.
+const fuzzyThreshold = 0.3
+
+// suggestCandidates is how many similar titles are mined for "did you mean" words.
+const suggestCandidates = 50
+
+func createSearchIndex(db *gorm.DB) error {
+	return db.Exec(documentSearchSQL).Error
+}
+
+// setFuzzyThreshold applies fuzzyThreshold to the <% operator for the rest of the transaction.
+func setFuzzyThreshold(tx *gorm.DB) error {
+	return tx.Exec("SELECT set_config('pg_trgm.word_similarity_threshold', ?, true)", strconv.FormatFloat(fuzzyThreshold, 'f', -1, 64)).Error
+}
+
+// SearchDocuments returns documents in scope matching the filter, most relevant first.
+// Full-text matches are ranked with ts_rank, fuzzy ones by trigram word similarity.
+func (r *Repository) SearchDocuments(filter repository.DocumentSearchFilter, scope repository.AccessScope) ([]repository.DocumentSearchHit, error) {
+	if filter.Fuzzy {
+		var hits []repository.DocumentSearchHit
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			if err := setFuzzyThreshold(tx); err != nil {
+				return err
+			}
+			query := tx.Model(&entity.Document{}).Scopes(documentAccess(scope), searchFilters(filter)).
+				Select("documents.id, word_similarity(?, documents.title) AS rank", filter.Query).
+				Where("? <% documents.title", filter.Query)
+			var err error
+			hits, err = (&Repository{db: tx}).rankedHits(query, filter, false)
+			return err
+		})
+		return hits, err
+	}
+
+	query := r.db.Model(&entity.Document{}).Scopes(documentAccess(scope), searchFilters(filter)).
+		Select("documents.id, ts_rank(documents.search_vector, "+searchQuery+") AS rank", filter.Query, filter.Query).
+		Where("documents.search_vector @@ "+searchQuery, filter.Query, filter.Query)
+	return r.rankedHits(query, filter, true)
+}
+
+// searchFilters applies the optional folder, type and date filters of a search.
+func searchFilters(filter repository.DocumentSearchFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.FolderID != nil {
+			db = db.Where("documents.folder_id = ?", *filter.FolderID)
+		}
+		if filter.DocumentTypeID != nil {
+			db = db.Where("documents.document_type_id = ?", *filter.DocumentTypeID)
+		}
+		if filter.From != nil {
+			db = db.Where("documents.created_at >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			db = db.Where("documents.created_at < ?", *filter.To)
+		}
+		if filter.Limit > 0 {
+			db = db.Limit(filter.Limit)
+		}
+		return db
+	}
+}
+
+// rankedHits runs a query selecting (id, rank) and loads the matching documents in
+// rank order. With highlight set, titles get full-text headlines; otherwise the
+// plain title is used.
+func (r *Repository) rankedHits(query *gorm.DB, filter repository.DocumentSearchFilter, highlight bool) ([]repository.DocumentSearchHit, error) {
+	var ranked []struct {
+		ID   uint
+		Rank float64
+	}
+	if err := query.Order("rank DESC, documents.id").Scan(&ranked).Error; err != nil {
+		return nil, err
+	}
+	if len(ranked) == 0 {
+		return []repository.DocumentSearchHit{}, nil
+	}
+
+	ids := make([]uint, len(ranked))
+	for i, row := range ranked {
+		ids[i] = row.ID
+	}
+
+	// Headlines are costly, so they are only built for the page being returned
+	headlineByID := make(map[uint]string, len(ids))
+	if highlight {
+		var headlines []struct {
+			ID       uint
+			Headline string
+		}
+		err := r.db.Model(&entity.Document{}).
+			Select("id, ts_headline('russian', title, "+searchQuery+", ?) AS headline", filter.Query, filter.Query, searchHeadlineOptions).
+			Where("id IN ?", ids).
+			Scan(&headlines).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range headlines {
+			headlineByID[row.ID] = row.Headline
+		}
+	}
+
+	var documents []entity.Document
+	if err := r.db.Preload("Folder").Preload("DocumentType").Where("id IN ?", ids).Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	documentByID := make(map[uint]entity.Document, len(documents))
+	for _, document := range documents {
+		documentByID[document.ID] = document
+	}
+
+	hits := make([]repository.DocumentSearchHit, 0, len(ranked))
+	for _, row := range ranked {
+		document, ok := documentByID[row.ID]
+		if !ok {
+			continue
+		}
+		headline, ok := headlineByID[row.ID]
+		if !ok {
+			headline = document.Title
+		}
+		hits = append(hits, repository.DocumentSearchHit{Document: document, Rank: row.Rank, Headline: headlineHTML(headline, ok)})
+	}
+	return hits, nil
+}
+
+// SuggestSearchQuery proposes a corrected query by replacing each word with the
+// most similar word found in titles in scope. It returns "" if nothing better is found.
+func (r *Repository) SuggestSearchQuery(query string, scope repository.AccessScope) (string, error) {
+	words := strings.FieldsFunc(strings.ToLower(query), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+
+	changed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := setFuzzyThreshold(tx); err != nil {
+			return err
+		}
+		for i, word := range words {
+			titles := tx.Model(&entity.Document{}).Scopes(documentAccess(scope)).
+				Select("documents.title").
+				Where("? <% documents.title", word).
+				Limit(suggestCandidates)
+
+			var best []struct {
+				Word  string
+				Score float64
+			}
+			err := tx.Table("(?) AS candidates", titles).
+				Select("candidate.word AS word, similarity(candidate.word, ?) AS score", word).
+				Joins("CROSS JOIN LATERAL regexp_split_to_table(lower(candidates.title), '[^[:alnum:]]+') AS candidate(word)").
+				Where("candidate.word <> '' AND similarity(candidate.word, ?) >= ?", word, fuzzyThreshold).
+				Order("score DESC, candidate.word").
+				Limit(1).
+				Scan(&best).Error
+			if err != nil {
+				return err
+			}
+			if len(best) > 0 && best[0].Word != word {
+				words[i] = best[0].Word
+				changed = true
+			}
+		}
+		return nil
+	})
+	if err != nil || !changed {
+		return "", err
+	}
+	return strings.Join(words, " "), nil
+}
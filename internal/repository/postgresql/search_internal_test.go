@@ -0,0 +1,22 @@
+package postgresql
+
+import "testing"
+
+func TestHeadlineHTML(t *testing.T) {
+	tests := []struct {
+		marked      string
+		highlighted bool
+		want        string
+	}{
+		{"Annual " + headlineStart + "report" + headlineStop + " 2024", true, "Annual <b>report</b> 2024"},
+		{"<script>alert(1)</script> " + headlineStart + "invoice" + headlineStop, true, "&lt;script&gt;alert(1)&lt;/script&gt; <b>invoice</b>"},
+		{`Tom & Jerry's "deal"`, false, "Tom &amp; Jerry&#39;s &#34;deal&#34;"},
+		// Markers typed into a title that was not highlighted are no tags
+		{"typed " + headlineStart + "marker" + headlineStop, false, "typed marker"},
+	}
+	for _, tt := range tests {
+		if got := headlineHTML(tt.marked, tt.highlighted); got != tt.want {
+			t.Errorf("headlineHTML(%q) = %q, want %q", tt.marked, got, tt.want)
+		}
+	}
+}
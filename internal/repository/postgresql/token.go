@@ -0,0 +1,34 @@
+package postgresql
+
+import (
+	"time"
+
+	"folder-system/internal/entity"
+
+	"gorm.io/gorm/clause"
+)
+
+func (r *Repository) CreateRefreshToken(token *entity.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+// LockRefreshTokenByJTI loads a refresh token with SELECT ... FOR UPDATE so that two
+// concurrent refreshes with the same token cannot both rotate it.
+func (r *Repository) LockRefreshTokenByJTI(jti string) (*entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	result := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("jti = ?", jti).First(&token)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &token, nil
+}
+
+func (r *Repository) UpdateRefreshToken(token *entity.RefreshToken) error {
+	return r.db.Save(token).Error
+}
+
+func (r *Repository) RevokeRefreshTokenFamily(familyID string, at time.Time) error {
+	return r.db.Model(&entity.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
@@ -0,0 +1,39 @@
+package postgresql
+
+import "folder-system/internal/entity"
+
+func (r *Repository) CreateDocumentType(documentType *entity.DocumentType) error {
+	return r.db.Create(documentType).Error
+}
+
+func (r *Repository) ListDocumentTypes() ([]entity.DocumentType, error) {
+	var documentTypes []entity.DocumentType
+	if err := r.db.Order("id").Find(&documentTypes).Error; err != nil {
+		return nil, err
+	}
+	return documentTypes, nil
+}
+
+func (r *Repository) CreateFolderType(folderType *entity.FolderType) error {
+	return r.db.Create(folderType).Error
+}
+
+func (r *Repository) ListFolderTypes() ([]entity.FolderType, error) {
+	var folderTypes []entity.FolderType
+	if err := r.db.Order("id").Find(&folderTypes).Error; err != nil {
+		return nil, err
+	}
+	return folderTypes, nil
+}
+
+func (r *Repository) GetDocumentType(id uint) (*entity.DocumentType, error) {
+	var documentType entity.DocumentType
+	if err := r.db.First(&documentType, id).Error; err != nil {
+		return nil, err
+	}
+	return &documentType, nil
+}
+
+func (r *Repository) UpdateDocumentType(documentType *entity.DocumentType) error {
+	return r.db.Save(documentType).Error
+}
@@ -0,0 +1,53 @@
+package postgresql
+
+import (
+	"time"
+
+	"folder-system/internal/entity"
+
+	"gorm.io/gorm/clause"
+)
+
+func (r *Repository) CreateUpload(upload *entity.Upload) error {
+	return r.db.Create(upload).Error
+}
+
+func (r *Repository) GetUpload(id string, userID uint) (*entity.Upload, error) {
+	var upload entity.Upload
+	result := r.db.Where("id = ? AND created_by = ?", id, userID).First(&upload)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &upload, nil
+}
+
+// LockUpload loads an upload with SELECT ... FOR UPDATE so chunks of one upload are
+// appended one at a time. It must be called inside a transaction.
+func (r *Repository) LockUpload(id string, userID uint) (*entity.Upload, error) {
+	var upload entity.Upload
+	result := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND created_by = ?", id, userID).
+		First(&upload)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &upload, nil
+}
+
+func (r *Repository) UpdateUpload(upload *entity.Upload) error {
+	return r.db.Save(upload).Error
+}
+
+func (r *Repository) DeleteUpload(id string) error {
+	return r.db.Delete(&entity.Upload{}, "id = ?", id).Error
+}
+
+// ListExpiredUploads returns unfinished uploads whose expiry time has passed.
+func (r *Repository) ListExpiredUploads(now time.Time) ([]entity.Upload, error) {
+	var uploads []entity.Upload
+	result := r.db.Where("expires_at < ? AND completed_at IS NULL", now).Find(&uploads)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return uploads, nil
+}
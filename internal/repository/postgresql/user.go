@@ -14,3 +14,16 @@ func (r *Repository) GetUserByEmail(email string) (*entity.User, error) {
 	}
 	return &user, nil
 }
+
+func (r *Repository) GetUserByID(id uint) (*entity.User, error) {
+	var user entity.User
+	result := r.db.First(&user, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
+func (r *Repository) UpdateUser(user *entity.User) error {
+	return r.db.Save(user).Error
+}
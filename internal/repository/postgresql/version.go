@@ -0,0 +1,38 @@
+package postgresql
+
+import "folder-system/internal/entity"
+
+func (r *Repository) CreateDocumentVersion(version *entity.DocumentVersion) error {
+	return r.db.Create(version).Error
+}
+
+func (r *Repository) ListDocumentVersions(documentID uint) ([]entity.DocumentVersion, error) {
+	var versions []entity.DocumentVersion
+	result := r.db.Where("document_id = ?", documentID).Order("version").Find(&versions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return versions, nil
+}
+
+func (r *Repository) GetDocumentVersion(documentID uint, version int) (*entity.DocumentVersion, error) {
+	var documentVersion entity.DocumentVersion
+	result := r.db.Where("document_id = ? AND version = ?", documentID, version).First(&documentVersion)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &documentVersion, nil
+}
+
+// LatestDocumentVersion returns the highest version number of a document, or 0 if it has none.
+func (r *Repository) LatestDocumentVersion(documentID uint) (int, error) {
+	var latest int
+	result := r.db.Model(&entity.DocumentVersion{}).
+		Where("document_id = ?", documentID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return latest, nil
+}
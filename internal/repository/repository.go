@@ -1,25 +1,333 @@
 package repository
 
-import "folder-system/internal/entity"
+import (
+	"errors"
+	"time"
+
+	"folder-system/internal/entity"
+)
+
+var (
+	// ErrNotFound is returned when the record a write depends on does not exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrNotEnoughSpace is returned when a folder cannot take the requested number of sheets.
+	ErrNotEnoughSpace = errors.New("not enough free sheets in folder")
+	// ErrNotEnoughUsed is returned when a folder is asked to free more sheets than it holds.
+	ErrNotEnoughUsed = errors.New("folder holds fewer sheets than are being released")
+)
+
+// AccessScope describes on whose behalf a query runs. Records outside the scope
+// behave as if they did not exist.
+type AccessScope struct {
+	UserID uint
+	// Unrestricted lifts the ownership filter, e.g. for admins.
+	Unrestricted bool
+	// Permission is the folder grant needed to reach records the user does not own.
+	// Empty means read.
+	Permission string
+}
 
 // UserRepository defines the interface for user data access.
 type UserRepository interface {
 	CreateUser(user *entity.User) error
 	GetUserByEmail(email string) (*entity.User, error)
+	GetUserByID(id uint) (*entity.User, error)
+	UpdateUser(user *entity.User) error
+}
+
+// RefreshTokenRepository defines the interface for issued refresh token tracking.
+type RefreshTokenRepository interface {
+	CreateRefreshToken(token *entity.RefreshToken) error
+	LockRefreshTokenByJTI(jti string) (*entity.RefreshToken, error)
+	UpdateRefreshToken(token *entity.RefreshToken) error
+	RevokeRefreshTokenFamily(familyID string, at time.Time) error
 }
 
 // FolderRepository defines the interface for folder data access.
 type FolderRepository interface {
 	CreateFolder(folder *entity.Folder) error
-	GetFolderByID(id uint) (*entity.Folder, error)
+	GetFolderByID(id uint, scope AccessScope) (*entity.Folder, error)
+	LockFolderByID(id uint, scope AccessScope) (*entity.Folder, error)
+	ListFolders(filter FolderListFilter, scope AccessScope) ([]entity.Folder, error)
 	UpdateFolder(folder *entity.Folder) error
-	FindFolderByTypeAndCapacity(folderTypeID uint, sheetsRequired int) (*entity.Folder, error)
+	DeleteFolder(id uint) error
+	CountDocumentsInFolder(folderID uint) (int64, error)
+	CountDocumentsByFolder(folderIDs []uint) (map[uint]int64, error)
+	UnfileDocumentsInFolder(folderID uint) error
+	DeleteDocumentsInFolder(folderID uint) error
+	ListDisposableFolders(at time.Time) ([]FolderDisposal, error)
+	FindFoldersByTypeAndCapacity(folderTypeID uint, sheetsRequired int, containerIDs []uint, scope AccessScope) ([]entity.Folder, error)
+	ReserveSheets(folderID uint, sheets int) error
+	ReleaseSheets(folderID uint, sheets int) error
+}
+
+// ContainerRepository defines the interface for the physical storage hierarchy.
+type ContainerRepository interface {
+	CreateContainer(container *entity.Container) error
+	GetContainerByID(id uint) (*entity.Container, error)
+	LockContainerByID(id uint) (*entity.Container, error)
+	ListContainers() ([]entity.Container, error)
+	UpdateContainer(container *entity.Container) error
+	DeleteContainer(id uint) error
+	CountContainerChildren(containerIDs []uint) (map[uint]int64, error)
+	ContainerPath(id uint) ([]entity.Container, error)
+	ContainerSubtreeIDs(id uint) ([]uint, error)
+}
+
+// FolderLocationRepository defines the interface for folder location history.
+// History entries are never changed, so there are no update or delete methods.
+type FolderLocationRepository interface {
+	CreateFolderLocation(location *entity.FolderLocation) error
+	CurrentFolderLocation(folderID uint) (*entity.FolderLocation, error)
+	ListFolderLocations(folderID uint) ([]entity.FolderLocation, error)
+}
+
+// LoanFilter narrows down a loan listing. Zero values mean "any".
+type LoanFilter struct {
+	// ActiveOnly leaves out returned loans.
+	ActiveOnly bool
+	// OverdueAt keeps only active loans that were due before this time.
+	OverdueAt  *time.Time
+	FolderID   *uint
+	DocumentID *uint
+	// UserID keeps loans the user borrowed or handed out.
+	UserID *uint
+}
+
+// LoanRepository defines the interface for lending physical folders and documents.
+type LoanRepository interface {
+	CreateLoan(loan *entity.Loan) error
+	LockLoan(id uint) (*entity.Loan, error)
+	UpdateLoan(loan *entity.Loan) error
+	ActiveFolderLoan(folderID uint) (*entity.Loan, error)
+	ActiveDocumentLoan(documentID uint) (*entity.Loan, error)
+	CountActiveDocumentLoansInFolder(folderID uint) (int64, error)
+	ListLoans(filter LoanFilter) ([]entity.Loan, error)
+}
+
+// ScanSessionRepository defines the interface for barcode scan sessions. Sessions
+// are only visible to the user who started them.
+type ScanSessionRepository interface {
+	CreateScanSession(session *entity.ScanSession) error
+	LockScanSession(id, userID uint) (*entity.ScanSession, error)
+	UpdateScanSession(session *entity.ScanSession) error
+}
+
+// InventoryRepository defines the interface for stock-take sessions.
+type InventoryRepository interface {
+	CreateInventorySession(session *entity.InventorySession) error
+	LockInventorySession(id uint) (*entity.InventorySession, error)
+	UpdateInventorySession(session *entity.InventorySession) error
+	RecordInventoryFolder(folder *entity.InventoryFolder, items []entity.InventoryItem) error
+	ListInventoryFolders(sessionID uint) ([]entity.InventoryFolder, error)
+	ListInventoryItems(sessionID uint) ([]entity.InventoryItem, error)
+}
+
+// Folder list sort keys.
+const (
+	FolderSortID         = "id"
+	FolderSortName       = "name"
+	FolderSortFill       = "fill"
+	FolderSortFreeSheets = "free_sheets"
+)
+
+// FolderListFilter narrows down and orders a folder listing. Zero values mean "any".
+type FolderListFilter struct {
+	FolderTypeID  *uint
+	MinFreeSheets *int
+	SortBy        string
+	Desc          bool
 }
 
 // DocumentRepository defines the interface for document data access.
 type DocumentRepository interface {
 	CreateDocument(document *entity.Document) error
-	GetDocumentByID(id uint) (*entity.Document, error)
+	GetDocumentByID(id uint, scope AccessScope) (*entity.Document, error)
+	LockDocumentByID(id uint, scope AccessScope) (*entity.Document, error)
 	UpdateDocument(document *entity.Document) error
 	DeleteDocument(id uint) error
+	ListDocumentsInFolder(folderID uint) ([]entity.Document, error)
+	ListDocumentsByIDs(ids []uint, scope AccessScope) ([]entity.Document, error)
+	DocumentDisposalDate(id uint) (*time.Time, error)
+	ListDisposableDocuments(at time.Time) ([]entity.Document, error)
+	FindDocumentsByFileHash(hash string, excludeID uint, scope AccessScope) ([]entity.Document, error)
+	SearchDocuments(filter DocumentSearchFilter, scope AccessScope) ([]DocumentSearchHit, error)
+	SuggestSearchQuery(query string, scope AccessScope) (string, error)
+	ListDocuments(filter DocumentListFilter, scope AccessScope) ([]entity.Document, error)
+	CountDocuments(filter DocumentListFilter, scope AccessScope) (int64, error)
+}
+
+// FolderDisposal is a folder whose documents have all reached the end of their
+// retention period. DisposalDate is the latest of their disposal dates.
+type FolderDisposal struct {
+	FolderID      uint
+	DisposalDate  time.Time
+	DocumentCount int64
+}
+
+// Document list sort keys.
+const (
+	DocumentSortCreatedAt   = "created_at"
+	DocumentSortTitle       = "title"
+	DocumentSortSheetsCount = "sheets_count"
+	DocumentSortID          = "id"
+)
+
+// DocumentCursor is a position in a sorted document list: the sort key value and
+// ID of a document. Value is a time.Time, string or int depending on the sort key.
+type DocumentCursor struct {
+	Value interface{}
+	ID    uint
+}
+
+// DocumentListFilter narrows down and orders a document listing. Zero values mean
+// "any". At most one of After and Before is set; the page starts right after or
+// ends right before that position.
+type DocumentListFilter struct {
+	FolderID       *uint
+	DocumentTypeID *uint
+	Unfiled        bool
+	From           *time.Time
+	To             *time.Time
+	MinSheets      *int
+	MaxSheets      *int
+	SortBy         string
+	Desc           bool
+	After          *DocumentCursor
+	Before         *DocumentCursor
+	Limit          int
+}
+
+// DocumentSearchFilter describes a full-text document search. Query uses web search
+// syntax ("quoted phrases", OR, -excluded); the other fields are optional. Fuzzy
+// matches Query against titles by trigram similarity instead, tolerating typos.
+type DocumentSearchFilter struct {
+	Query          string
+	Fuzzy          bool
+	FolderID       *uint
+	DocumentTypeID *uint
+	From           *time.Time
+	To             *time.Time
+	Limit          int
+}
+
+// DocumentSearchHit is a document matching a search, with its relevance and the
+// title as HTML: escaped, with matched words wrapped in <b></b>, so it can be
+// inserted into a page as is. Fuzzy hits are ranked by similarity from 0 to 1 and
+// are not highlighted.
+type DocumentSearchHit struct {
+	Document entity.Document `json:"document"`
+	Rank     float64         `json:"rank"`
+	Headline string          `json:"headline"`
+}
+
+// BlobRepository defines the interface for reference counted, content-addressed
+// file contents.
+type BlobRepository interface {
+	LockBlob(hash string) (*entity.Blob, error)
+	CreateBlob(blob *entity.Blob) error
+	UpdateBlob(blob *entity.Blob) error
+	DeleteBlob(hash string) error
+}
+
+// DocumentVersionRepository defines the interface for document revision history.
+// Versions are immutable, so there are no update or delete methods.
+type DocumentVersionRepository interface {
+	CreateDocumentVersion(version *entity.DocumentVersion) error
+	ListDocumentVersions(documentID uint) ([]entity.DocumentVersion, error)
+	GetDocumentVersion(documentID uint, version int) (*entity.DocumentVersion, error)
+	LatestDocumentVersion(documentID uint) (int, error)
+}
+
+// UploadRepository defines the interface for resumable upload bookkeeping.
+// Uploads are only visible to the user who started them.
+type UploadRepository interface {
+	CreateUpload(upload *entity.Upload) error
+	GetUpload(id string, userID uint) (*entity.Upload, error)
+	LockUpload(id string, userID uint) (*entity.Upload, error)
+	UpdateUpload(upload *entity.Upload) error
+	DeleteUpload(id string) error
+	ListExpiredUploads(now time.Time) ([]entity.Upload, error)
+}
+
+// TypeRepository defines the interface for document and folder type data access.
+type TypeRepository interface {
+	CreateDocumentType(documentType *entity.DocumentType) error
+	ListDocumentTypes() ([]entity.DocumentType, error)
+	GetDocumentType(id uint) (*entity.DocumentType, error)
+	UpdateDocumentType(documentType *entity.DocumentType) error
+	CreateFolderType(folderType *entity.FolderType) error
+	ListFolderTypes() ([]entity.FolderType, error)
+}
+
+// GroupRepository defines the interface for user groups data access.
+type GroupRepository interface {
+	CreateGroup(group *entity.Group) error
+	GetGroupByID(id uint) (*entity.Group, error)
+	ListGroupsForUser(userID uint) ([]entity.Group, error)
+	AddGroupMember(member *entity.GroupMember) error
+	RemoveGroupMember(groupID, userID uint) error
+}
+
+// FolderGrantRepository defines the interface for folder sharing data access.
+type FolderGrantRepository interface {
+	CreateFolderGrant(grant *entity.FolderGrant) error
+	GetFolderGrant(folderID, grantID uint) (*entity.FolderGrant, error)
+	FindFolderGrant(folderID uint, userID, groupID *uint, permission string) (*entity.FolderGrant, error)
+	ListFolderGrants(folderID uint) ([]entity.FolderGrant, error)
+	DeleteFolderGrant(folderID, grantID uint) error
+}
+
+// AuditFilter narrows down an audit trail query. Zero values mean "any".
+type AuditFilter struct {
+	EntityType string
+	EntityID   *uint
+	ActorID    *uint
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+}
+
+// AuditRepository defines the interface for the append-only audit trail.
+// There are deliberately no update or delete methods.
+type AuditRepository interface {
+	CreateAuditRecord(record *entity.AuditRecord) error
+	ListAuditRecords(filter AuditFilter) ([]entity.AuditRecord, error)
+	ListAuditChain(afterID uint, limit int) ([]entity.AuditRecord, error)
+}
+
+// FolderTypeAssignmentRepository defines the interface for document type -> folder type mappings.
+type FolderTypeAssignmentRepository interface {
+	CreateFolderTypeAssignment(assignment *entity.FolderTypeAssignment) error
+	GetFolderTypeAssignmentByID(id uint) (*entity.FolderTypeAssignment, error)
+	ListFolderTypeAssignments(documentTypeID *uint) ([]entity.FolderTypeAssignment, error)
+	UpdateFolderTypeAssignment(assignment *entity.FolderTypeAssignment) error
+	DeleteFolderTypeAssignment(id uint) error
+}
+
+// Store bundles every repository so that several writes can share one transaction.
+type Store interface {
+	UserRepository
+	RefreshTokenRepository
+	FolderRepository
+	ContainerRepository
+	FolderLocationRepository
+	LoanRepository
+	ScanSessionRepository
+	InventoryRepository
+	DocumentRepository
+	DocumentVersionRepository
+	BlobRepository
+	UploadRepository
+	TypeRepository
+	FolderTypeAssignmentRepository
+	GroupRepository
+	FolderGrantRepository
+	AuditRepository
+}
+
+// Transactor runs fn inside a single database transaction. The Store passed to fn
+// is bound to that transaction; returning an error from fn rolls everything back.
+type Transactor interface {
+	Transaction(fn func(tx Store) error) error
 }
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+)
+
+var ErrAssignmentNotFound = errors.New("folder type assignment not found")
+
+type AssignmentService interface {
+	CreateAssignment(documentTypeID, folderTypeID uint, priority int) (*entity.FolderTypeAssignment, error)
+	GetAssignment(id uint) (*entity.FolderTypeAssignment, error)
+	ListAssignments(documentTypeID *uint) ([]entity.FolderTypeAssignment, error)
+	UpdateAssignment(id uint, folderTypeID *uint, priority *int) (*entity.FolderTypeAssignment, error)
+	DeleteAssignment(id uint) error
+}
+
+type assignmentService struct {
+	assignmentRepo repository.FolderTypeAssignmentRepository
+}
+
+func NewAssignmentService(assignmentRepo repository.FolderTypeAssignmentRepository) AssignmentService {
+	return &assignmentService{assignmentRepo: assignmentRepo}
+}
+
+func (s *assignmentService) CreateAssignment(documentTypeID, folderTypeID uint, priority int) (*entity.FolderTypeAssignment, error) {
+	assignment := &entity.FolderTypeAssignment{
+		DocumentTypeID: documentTypeID,
+		FolderTypeID:   folderTypeID,
+		Priority:       priority,
+	}
+
+	if err := s.assignmentRepo.CreateFolderTypeAssignment(assignment); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+func (s *assignmentService) GetAssignment(id uint) (*entity.FolderTypeAssignment, error) {
+	assignment, err := s.assignmentRepo.GetFolderTypeAssignmentByID(id)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+	return assignment, nil
+}
+
+func (s *assignmentService) ListAssignments(documentTypeID *uint) ([]entity.FolderTypeAssignment, error) {
+	return s.assignmentRepo.ListFolderTypeAssignments(documentTypeID)
+}
+
+func (s *assignmentService) UpdateAssignment(id uint, folderTypeID *uint, priority *int) (*entity.FolderTypeAssignment, error) {
+	assignment, err := s.assignmentRepo.GetFolderTypeAssignmentByID(id)
+	if err != nil {
+		return nil, ErrAssignmentNotFound
+	}
+
+	if folderTypeID != nil {
+		assignment.FolderTypeID = *folderTypeID
+	}
+	if priority != nil {
+		assignment.Priority = *priority
+	}
+
+	if err := s.assignmentRepo.UpdateFolderTypeAssignment(assignment); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+func (s *assignmentService) DeleteAssignment(id uint) error {
+	if _, err := s.assignmentRepo.GetFolderTypeAssignmentByID(id); err != nil {
+		return ErrAssignmentNotFound
+	}
+	return s.assignmentRepo.DeleteFolderTypeAssignment(id)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"encoding/json"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+	"reflect"
+)
+
+// AuditFilter narrows down an audit trail query.
+type AuditFilter = repository.AuditFilter
+
+// Fields that change on every write and would only add noise to a diff.
+var auditIgnoredFields = map[string]bool{"UpdatedAt": true}
+
+// auditVerifyBatchSize is how many records are loaded at a time while verifying the chain.
+const auditVerifyBatchSize = 500
+
+// AuditVerification is the outcome of walking the audit hash chain.
+type AuditVerification struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`
+	Legacy   int    `json:"legacy"`
+	Broken   *uint  `json:"broken_at_id,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	HeadID   uint   `json:"head_id,omitempty"`
+	HeadHash string `json:"head_hash,omitempty"`
+}
+
+type AuditService interface {
+	ListAuditRecords(filter AuditFilter) ([]entity.AuditRecord, error)
+	VerifyAuditChain() (*AuditVerification, error)
+}
+
+type auditService struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditService(auditRepo repository.AuditRepository) AuditService {
+	return &auditService{auditRepo: auditRepo}
+}
+
+func (s *auditService) ListAuditRecords(filter AuditFilter) ([]entity.AuditRecord, error) {
+	return s.auditRepo.ListAuditRecords(filter)
+}
+
+// VerifyAuditChain walks the whole audit trail from the oldest record and reports
+// the first record whose hash or link to its predecessor does not check out.
+// Records written before chaining was introduced carry no hash; a leading run of
+// them is counted as legacy and skipped.
+func (s *auditService) VerifyAuditChain() (*AuditVerification, error) {
+	result := &AuditVerification{Valid: true}
+	prevHash := ""
+	chained := false
+	var afterID uint
+
+	for {
+		records, err := s.auditRepo.ListAuditChain(afterID, auditVerifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return result, nil
+		}
+
+		for i := range records {
+			record := &records[i]
+			afterID = record.ID
+
+			if !chained && record.Hash == "" {
+				result.Legacy++
+				continue
+			}
+			chained = true
+
+			if record.PrevHash != prevHash {
+				return result.broken(record.ID, "prev_hash does not match the previous record"), nil
+			}
+			hash, err := record.ComputeHash()
+			if err != nil {
+				return nil, err
+			}
+			if hash != record.Hash {
+				return result.broken(record.ID, "record content does not match its hash"), nil
+			}
+
+			prevHash = record.Hash
+			result.Checked++
+			result.HeadID = record.ID
+			result.HeadHash = record.Hash
+		}
+	}
+}
+
+func (v *AuditVerification) broken(id uint, reason string) *AuditVerification {
+	v.Valid = false
+	v.Broken = &id
+	v.Reason = reason
+	return v
+}
+
+// recordAudit appends an audit record for a mutation. before is nil for creations,
+// after is nil for deletions. It is meant to be called with the transaction-bound
+// repository so the record is committed together with the change itself.
+func recordAudit(auditRepo repository.AuditRepository, actor Actor, action, entityType string, entityID uint, before, after interface{}) error {
+	record := &entity.AuditRecord{
+		ActorID:    actor.UserID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+	}
+
+	beforeFields, err := auditSnapshot(before, &record.Before)
+	if err != nil {
+		return err
+	}
+	afterFields, err := auditSnapshot(after, &record.After)
+	if err != nil {
+		return err
+	}
+
+	changes := make(map[string]entity.FieldChange)
+	for field, value := range afterFields {
+		if !auditIgnoredFields[field] && !reflect.DeepEqual(beforeFields[field], value) {
+			changes[field] = entity.FieldChange{From: beforeFields[field], To: value}
+		}
+	}
+	for field, value := range beforeFields {
+		if _, ok := afterFields[field]; !ok && !auditIgnoredFields[field] {
+			changes[field] = entity.FieldChange{From: value, To: nil}
+		}
+	}
+	if record.Changes, err = entity.NewJSON(changes); err != nil {
+		return err
+	}
+
+	return auditRepo.CreateAuditRecord(record)
+}
+
+// auditSnapshot stores v as JSON in dst and returns its top-level fields.
+func auditSnapshot(v interface{}, dst *entity.JSON) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+
+	snapshot, err := entity.NewJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	*dst = snapshot
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(snapshot, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -6,23 +6,36 @@ import (
 	"folder-system/internal/entity"
 	"folder-system/internal/repository"
 	"folder-system/internal/utils"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrUserNotFound        = errors.New("user not found")
+	ErrInvalidRole         = errors.New("unknown role")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+)
+
 type AuthService interface {
 	Register(email, password string) error
 	Login(email, password string) (accessToken, refreshToken string, err error)
 	RefreshTokens(refreshToken string) (newAccessToken, newRefreshToken string, err error)
+	Logout(refreshToken string) error
+	UpdateUserRole(userID uint, role string) (*entity.User, error)
+	PromoteAdmin(email string) (*entity.User, error)
 }
 
 type authService struct {
-	userRepo repository.UserRepository
-	cfg      *config.Config
+	userRepo   repository.UserRepository
+	tokenRepo  repository.RefreshTokenRepository
+	transactor repository.Transactor
+	cfg        *config.Config
 }
 
-func NewAuthService(userRepo repository.UserRepository, cfg *config.Config) AuthService {
-	return &authService{userRepo: userRepo, cfg: cfg}
+func NewAuthService(userRepo repository.UserRepository, tokenRepo repository.RefreshTokenRepository, transactor repository.Transactor, cfg *config.Config) AuthService {
+	return &authService{userRepo: userRepo, tokenRepo: tokenRepo, transactor: transactor, cfg: cfg}
 }
 
 func (s *authService) Register(email, password string) error {
@@ -31,9 +44,12 @@ func (s *authService) Register(email, password string) error {
 		return err
 	}
 
+	// Self-registration is public, so it never grants more than clerk; admins are
+	// bootstrapped with PromoteAdmin and appoint further ones with UpdateUserRole
 	user := &entity.User{
 		Email:    email,
 		Password: string(hashedPassword),
+		Role:     entity.RoleClerk,
 	}
 
 	return s.userRepo.CreateUser(user)
@@ -50,37 +66,160 @@ func (s *authService) Login(email, password string) (string, string, error) {
 		return "", "", errors.New("invalid credentials")
 	}
 
-	accessToken, err := utils.GenerateJWT(user.ID, utils.AccessToken, s.cfg.JWT.AccessSecret, s.cfg.JWT.AccessTTL)
+	// Every login starts a new token family
+	familyID, err := utils.NewTokenID()
 	if err != nil {
 		return "", "", err
 	}
 
-	refreshToken, err := utils.GenerateJWT(user.ID, utils.RefreshToken, s.cfg.JWT.RefreshSecret, s.cfg.JWT.RefreshTTL)
+	return s.issueTokens(s.tokenRepo, user, familyID, nil)
+}
+
+// RefreshTokens rotates a refresh token: the presented token is marked as used and a
+// new pair is issued in the same family. Presenting a token that was already rotated
+// or revoked means it has leaked, so the whole family is revoked.
+func (s *authService) RefreshTokens(refreshToken string) (string, string, error) {
+	claims, err := s.parseRefreshToken(refreshToken)
 	if err != nil {
 		return "", "", err
 	}
 
-	return accessToken, refreshToken, nil
+	var newAccessToken, newRefreshToken, reusedFamily string
+	err = s.transactor.Transaction(func(tx repository.Store) error {
+		stored, err := tx.LockRefreshTokenByJTI(claims.ID)
+		if err != nil || stored.UserID != claims.UserID {
+			return ErrInvalidRefreshToken
+		}
+		if stored.RotatedAt != nil || stored.RevokedAt != nil {
+			reusedFamily = stored.FamilyID
+			return ErrRefreshTokenReused
+		}
+
+		// Reload the user so that role changes apply from the next refresh on
+		user, err := tx.GetUserByID(stored.UserID)
+		if err != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		now := time.Now()
+		stored.RotatedAt = &now
+		if err := tx.UpdateRefreshToken(stored); err != nil {
+			return err
+		}
+
+		newAccessToken, newRefreshToken, err = s.issueTokens(tx, user, stored.FamilyID, &stored.JTI)
+		return err
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		// Revoke outside the rolled back transaction so it sticks
+		if revokeErr := s.tokenRepo.RevokeRefreshTokenFamily(reusedFamily, time.Now()); revokeErr != nil {
+			return "", "", revokeErr
+		}
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	return newAccessToken, newRefreshToken, nil
 }
 
-func (s *authService) RefreshTokens(refreshToken string) (string, string, error) {
+// Logout revokes every refresh token in the family of the given token.
+func (s *authService) Logout(refreshToken string) error {
+	claims, err := s.parseRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+
+	return s.transactor.Transaction(func(tx repository.Store) error {
+		stored, err := tx.LockRefreshTokenByJTI(claims.ID)
+		if err != nil || stored.UserID != claims.UserID {
+			return ErrInvalidRefreshToken
+		}
+		return tx.RevokeRefreshTokenFamily(stored.FamilyID, time.Now())
+	})
+}
+
+func (s *authService) UpdateUserRole(userID uint, role string) (*entity.User, error) {
+	if !entity.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	user.Role = role
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// PromoteAdmin gives the admin role to the registered user with the given email,
+// the way the first administrator of an installation is appointed. It returns
+// ErrUserNotFound while nobody has registered with that email.
+func (s *authService) PromoteAdmin(email string) (*entity.User, error) {
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Role == entity.RoleAdmin {
+		return user, nil
+	}
+
+	user.Role = entity.RoleAdmin
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *authService) parseRefreshToken(refreshToken string) (*utils.Claims, error) {
 	claims, err := utils.ParseJWT(refreshToken, s.cfg.JWT.RefreshSecret)
 	if err != nil {
-		return "", "", errors.New("invalid refresh token")
+		return nil, ErrInvalidRefreshToken
 	}
 	if claims.Subject != string(utils.RefreshToken) {
-		return "", "", errors.New("token is not a refresh token")
+		return nil, errors.New("token is not a refresh token")
 	}
+	if claims.ID == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	return claims, nil
+}
 
-	newAccessToken, err := utils.GenerateJWT(claims.UserID, utils.AccessToken, s.cfg.JWT.AccessSecret, s.cfg.JWT.AccessTTL)
+// issueTokens signs a new access/refresh pair and records the refresh token.
+func (s *authService) issueTokens(tokenRepo repository.RefreshTokenRepository, user *entity.User, familyID string, parentJTI *string) (string, string, error) {
+	accessID, err := utils.NewTokenID()
+	if err != nil {
+		return "", "", err
+	}
+	refreshID, err := utils.NewTokenID()
 	if err != nil {
 		return "", "", err
 	}
 
-	newRefreshToken, err := utils.GenerateJWT(claims.UserID, utils.RefreshToken, s.cfg.JWT.RefreshSecret, s.cfg.JWT.RefreshTTL)
+	accessToken, err := utils.GenerateJWT(user.ID, user.Role, utils.AccessToken, accessID, s.cfg.JWT.AccessSecret, s.cfg.JWT.AccessTTL)
 	if err != nil {
 		return "", "", err
 	}
 
-	return newAccessToken, newRefreshToken, nil
+	refreshToken, err := utils.GenerateJWT(user.ID, user.Role, utils.RefreshToken, refreshID, s.cfg.JWT.RefreshSecret, s.cfg.JWT.RefreshTTL)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = tokenRepo.CreateRefreshToken(&entity.RefreshToken{
+		JTI:       refreshID,
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		ParentJTI: parentJTI,
+		ExpiresAt: time.Now().Add(time.Minute * time.Duration(s.cfg.JWT.RefreshTTL)),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
 }
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+	"folder-system/internal/storage"
+	"folder-system/internal/utils"
+	"io"
+	"log"
+)
+
+// Duplicate policies, see config.DeduplicationConfig.
+const (
+	DuplicateAllow = "allow"
+	DuplicateWarn  = "warn"
+	DuplicateBlock = "block"
+)
+
+var ErrDuplicateFile = errors.New("a document with identical content already exists")
+
+// DuplicateFileError is returned when the duplicate policy of the document type
+// blocks a file. DuplicateOf lists the existing documents the actor can see.
+type DuplicateFileError struct {
+	DuplicateOf []uint
+}
+
+func (e *DuplicateFileError) Error() string {
+	return ErrDuplicateFile.Error()
+}
+
+func (e *DuplicateFileError) Is(target error) bool {
+	return target == ErrDuplicateFile
+}
+
+// blobKey is where the content with the given SHA-256 lives in the blob store.
+// Content stored since uploads are staged has a unique suffix after it.
+func blobKey(hash string) string {
+	return "sha256/" + hash[:2] + "/" + hash
+}
+
+// duplicatePolicyFor returns the configured policy of a document type.
+// Unknown values fall back to warn so a typo never silently blocks filing.
+func (s *documentService) duplicatePolicyFor(documentTypeID uint) string {
+	policy := s.dedup.ByDocumentType[documentTypeID]
+	if policy == "" {
+		policy = s.dedup.DefaultPolicy
+	}
+	switch policy {
+	case DuplicateAllow, DuplicateBlock:
+		return policy
+	}
+	return DuplicateWarn
+}
+
+// checkDuplicates applies the duplicate policy of the document type to a file
+// with the given hash. It returns the visible duplicates to report, or a
+// *DuplicateFileError if the policy blocks the file.
+func (s *documentService) checkDuplicates(docRepo repository.DocumentRepository, actor Actor, documentTypeID uint, hash string, excludeID uint) ([]uint, error) {
+	policy := s.duplicatePolicyFor(documentTypeID)
+	if policy == DuplicateAllow {
+		return nil, nil
+	}
+
+	if policy == DuplicateBlock {
+		// Block on any duplicate, including ones the actor cannot see
+		all, err := docRepo.FindDocumentsByFileHash(hash, excludeID, repository.AccessScope{Unrestricted: true})
+		if err != nil {
+			return nil, err
+		}
+		if len(all) == 0 {
+			return nil, nil
+		}
+	}
+
+	visible, err := docRepo.FindDocumentsByFileHash(hash, excludeID, actor.scope())
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(visible))
+	for _, document := range visible {
+		ids = append(ids, document.ID)
+	}
+
+	if policy == DuplicateBlock {
+		return nil, &DuplicateFileError{DuplicateOf: ids}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return ids, nil
+}
+
+// stageBlob uploads content with the given hash to a key of its own, so the transfer
+// happens outside any transaction. acquireBlob keeps the staged copy if the content
+// is new; otherwise, or if the transaction fails, delete it afterwards.
+func stageBlob(store storage.BlobStore, hash string, r io.Reader, size int64, contentType string) (string, error) {
+	id, err := utils.NewTokenID()
+	if err != nil {
+		return "", err
+	}
+	key := blobKey(hash) + "-" + id
+	if err := store.Put(key, r, size, contentType); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// acquireBlob adds a reference to the content with the given hash. If no document
+// references it yet, the copy staged under stagedKey becomes the blob; the returned
+// blob's key tells whether it was kept.
+func acquireBlob(tx repository.Store, hash string, size int64, contentType, stagedKey string) (*entity.Blob, error) {
+	blob, err := tx.LockBlob(hash)
+	if err == nil {
+		blob.RefCount++
+		return blob, tx.UpdateBlob(blob)
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	blob = &entity.Blob{Hash: hash, Key: stagedKey, Size: size, MIMEType: contentType, RefCount: 1}
+	return blob, tx.CreateBlob(blob)
+}
+
+// releaseFile drops the reference the document holds on its file. It returns the
+// blob key to remove with removeUnreferencedBlob once the transaction commits, or
+// "" while other documents still use the content. Files attached before content
+// addressing have no blob record and belong to the document alone.
+func releaseFile(tx repository.Store, document *entity.Document) (string, error) {
+	if document.FileKey == "" {
+		return "", nil
+	}
+
+	blob, err := tx.LockBlob(document.FileHash)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && blob.Key != document.FileKey) {
+		return document.FileKey, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	blob.RefCount--
+	if blob.RefCount > 0 {
+		return "", tx.UpdateBlob(blob)
+	}
+	return blob.Key, tx.DeleteBlob(blob.Hash)
+}
+
+// removeUnreferencedBlob deletes content released by releaseFile. It runs after
+// the releasing transaction and deletes only while the blob lock confirms that no
+// document references the content; if it has been attached again in the meantime,
+// or the check fails, the content is kept. Failures are logged, not returned: the
+// release itself has already committed.
+func removeUnreferencedBlob(transactor repository.Transactor, store storage.BlobStore, hash, key string) {
+	err := transactor.Transaction(func(tx repository.Store) error {
+		blob, err := tx.LockBlob(hash)
+		if err == nil && blob.Key == key && blob.RefCount > 0 {
+			return nil
+		}
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		return store.Delete(key)
+	})
+	if err != nil {
+		log.Printf("Failed to remove unreferenced file %s: %v", key, err)
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"errors"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+	"strings"
+)
+
+var (
+	ErrContainerNotFound    = errors.New("container not found")
+	ErrContainerNotEmpty    = errors.New("container still holds containers or folders")
+	ErrContainerFull        = errors.New("container has no free place")
+	ErrInvalidContainer     = errors.New("name, a valid kind and a non-negative capacity are required")
+	ErrInvalidNesting       = errors.New("container kind cannot be placed there")
+	ErrContainerCycle       = errors.New("container cannot be moved into itself or its descendants")
+	ErrCapacityBelowContent = errors.New("capacity cannot be less than the number of children")
+)
+
+// ContainerSummary is a container with the number of places taken in it.
+type ContainerSummary struct {
+	entity.Container
+	Occupied int64 `json:"occupied"`
+}
+
+// Location describes where something is kept: the chain of containers from the
+// outermost one down, and the same as a readable "Room 1 / Shelf A / Box 3" path.
+// For folders, Current is the newest entry of the location history.
+type Location struct {
+	Containers []entity.Container     `json:"containers"`
+	Path       string                 `json:"path"`
+	Current    *entity.FolderLocation `json:"current,omitempty"`
+}
+
+type ContainerService interface {
+	CreateContainer(actor Actor, name, kind string, parentID *uint, capacity int) (*entity.Container, error)
+	GetContainer(id uint) (*ContainerSummary, error)
+	ListContainers() ([]ContainerSummary, error)
+	UpdateContainer(actor Actor, id uint, name *string, capacity *int) (*entity.Container, error)
+	DeleteContainer(actor Actor, id uint) error
+	MoveContainer(actor Actor, id uint, parentID *uint) (*entity.Container, error)
+	GetContainerLocation(id uint) (*Location, error)
+}
+
+type containerService struct {
+	containerRepo repository.ContainerRepository
+	transactor    repository.Transactor
+}
+
+func NewContainerService(containerRepo repository.ContainerRepository, transactor repository.Transactor) ContainerService {
+	return &containerService{containerRepo: containerRepo, transactor: transactor}
+}
+
+func (s *containerService) CreateContainer(actor Actor, name, kind string, parentID *uint, capacity int) (*entity.Container, error) {
+	if strings.TrimSpace(name) == "" || !entity.IsValidContainerKind(kind) || capacity < 0 {
+		return nil, ErrInvalidContainer
+	}
+
+	container := &entity.Container{
+		Name:      name,
+		Kind:      kind,
+		ParentID:  parentID,
+		Capacity:  capacity,
+		CreatedBy: actor.UserID,
+	}
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		if parentID != nil {
+			if err := placeInContainer(tx, *parentID, kind); err != nil {
+				return err
+			}
+		}
+		if err := tx.CreateContainer(container); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionCreate, entity.AuditEntityContainer, container.ID, nil, container)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
+func (s *containerService) GetContainer(id uint) (*ContainerSummary, error) {
+	container, err := s.containerRepo.GetContainerByID(id)
+	if err != nil {
+		return nil, ErrContainerNotFound
+	}
+	counts, err := s.containerRepo.CountContainerChildren([]uint{id})
+	if err != nil {
+		return nil, err
+	}
+	return &ContainerSummary{Container: *container, Occupied: counts[id]}, nil
+}
+
+func (s *containerService) ListContainers() ([]ContainerSummary, error) {
+	containers, err := s.containerRepo.ListContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(containers))
+	for i, container := range containers {
+		ids[i] = container.ID
+	}
+	counts, err := s.containerRepo.CountContainerChildren(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]ContainerSummary, len(containers))
+	for i, container := range containers {
+		summaries[i] = ContainerSummary{Container: container, Occupied: counts[container.ID]}
+	}
+	return summaries, nil
+}
+
+func (s *containerService) UpdateContainer(actor Actor, id uint, name *string, capacity *int) (*entity.Container, error) {
+	if (name != nil && strings.TrimSpace(*name) == "") || (capacity != nil && *capacity < 0) {
+		return nil, ErrInvalidContainer
+	}
+
+	var container *entity.Container
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		var err error
+		container, err = tx.LockContainerByID(id)
+		if err != nil {
+			return ErrContainerNotFound
+		}
+		before := *container
+
+		if capacity != nil && *capacity > 0 {
+			counts, err := tx.CountContainerChildren([]uint{id})
+			if err != nil {
+				return err
+			}
+			if counts[id] > int64(*capacity) {
+				return ErrCapacityBelowContent
+			}
+		}
+		if name != nil {
+			container.Name = *name
+		}
+		if capacity != nil {
+			container.Capacity = *capacity
+		}
+
+		if err := tx.UpdateContainer(container); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionUpdate, entity.AuditEntityContainer, id, &before, container)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
+func (s *containerService) DeleteContainer(actor Actor, id uint) error {
+	return s.transactor.Transaction(func(tx repository.Store) error {
+		container, err := tx.LockContainerByID(id)
+		if err != nil {
+			return ErrContainerNotFound
+		}
+		counts, err := tx.CountContainerChildren([]uint{id})
+		if err != nil {
+			return err
+		}
+		if counts[id] > 0 {
+			return ErrContainerNotEmpty
+		}
+
+		if err := tx.DeleteContainer(id); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionDelete, entity.AuditEntityContainer, id, container, nil)
+	})
+}
+
+// MoveContainer puts a container, with everything nested in it, into another
+// container. A nil or zero parentID makes it a top-level container.
+func (s *containerService) MoveContainer(actor Actor, id uint, parentID *uint) (*entity.Container, error) {
+	if parentID != nil && *parentID == 0 {
+		parentID = nil
+	}
+
+	var container *entity.Container
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		var err error
+		container, err = tx.LockContainerByID(id)
+		if err != nil {
+			return ErrContainerNotFound
+		}
+		before := *container
+
+		if parentID != nil && (container.ParentID == nil || *container.ParentID != *parentID) {
+			subtree, err := tx.ContainerSubtreeIDs(id)
+			if err != nil {
+				return err
+			}
+			for _, descendant := range subtree {
+				if descendant == *parentID {
+					return ErrContainerCycle
+				}
+			}
+			if err := placeInContainer(tx, *parentID, container.Kind); err != nil {
+				return err
+			}
+		}
+
+		container.ParentID = parentID
+		if err := tx.UpdateContainer(container); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionUpdate, entity.AuditEntityContainer, id, &before, container)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
+func (s *containerService) GetContainerLocation(id uint) (*Location, error) {
+	path, err := s.containerRepo.ContainerPath(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return nil, ErrContainerNotFound
+	}
+	return newLocation(path), nil
+}
+
+// placeInContainer checks that a child of the given kind fits into the container
+// and keeps the container locked until the transaction ends, so concurrent moves
+// cannot overfill it. An empty childKind stands for a folder.
+func placeInContainer(tx repository.Store, containerID uint, childKind string) error {
+	container, err := tx.LockContainerByID(containerID)
+	if err != nil {
+		return ErrContainerNotFound
+	}
+	if childKind != "" && !entity.CanContain(container.Kind, childKind) {
+		return ErrInvalidNesting
+	}
+
+	if container.Capacity > 0 {
+		counts, err := tx.CountContainerChildren([]uint{containerID})
+		if err != nil {
+			return err
+		}
+		if counts[containerID] >= int64(container.Capacity) {
+			return ErrContainerFull
+		}
+	}
+	return nil
+}
+
+func newLocation(path []entity.Container) *Location {
+	if path == nil {
+		path = []entity.Container{}
+	}
+	names := make([]string, len(path))
+	for i, container := range path {
+		names[i] = container.Name
+	}
+	return &Location{Containers: path, Path: strings.Join(names, " / ")}
+}
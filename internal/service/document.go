@@ -2,71 +2,167 @@ package service
 
 import (
 	"errors"
+	"folder-system/internal/config"
 	"folder-system/internal/entity"
 	"folder-system/internal/repository"
+	"folder-system/internal/storage"
+	"io"
+	"time"
 )
 
+var (
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrNotEnoughSpace   = errors.New("not enough space in the folder")
+	ErrVersionNotFound  = errors.New("document version not found")
+)
+
+// DocumentSearchFilter describes a full-text document search.
+type DocumentSearchFilter = repository.DocumentSearchFilter
+
+// DocumentSearchHit is a matching document with its rank and highlighted title.
+type DocumentSearchHit = repository.DocumentSearchHit
+
+// DocumentSearchResult holds the hits of a search. Suggestion is a corrected query
+// ("did you mean") offered when a full-text search finds nothing.
+type DocumentSearchResult struct {
+	Hits       []DocumentSearchHit `json:"hits"`
+	Suggestion string              `json:"suggestion,omitempty"`
+}
+
 type DocumentService interface {
-	CreateDocument(title string, sheetsCount int, folderID *uint, docTypeID uint) (*entity.Document, error)
-	GetDocument(id uint) (*entity.Document, error)
-	UpdateDocument(id uint, title *string, sheetsCount *int, folderID *uint) (*entity.Document, error)
-	DeleteDocument(id uint) error
+	CreateDocument(actor Actor, title string, sheetsCount int, folderID *uint, docTypeID uint, fileHash string, overrideCheckout bool) (*entity.Document, error)
+	GetDocument(actor Actor, id uint) (*entity.Document, error)
+	SearchDocuments(actor Actor, filter DocumentSearchFilter) (*DocumentSearchResult, error)
+	ListDocuments(actor Actor, query DocumentListQuery) (*DocumentPage, error)
+	UpdateDocument(actor Actor, id uint, title *string, sheetsCount *int, folderID *uint, overrideCheckout bool) (*entity.Document, error)
+	DeleteDocument(actor Actor, id uint) error
+	ListVersions(actor Actor, id uint) ([]entity.DocumentVersion, error)
+	RestoreVersion(actor Actor, id uint, version int, overrideCheckout bool) (*entity.Document, error)
+	AttachFile(actor Actor, id uint, fileName, contentType string, r io.ReadSeeker) (*entity.Document, error)
+	AttachFileWith(actor Actor, id uint, fileName, contentType string, r io.ReadSeeker, with func(tx repository.Store) error) (*entity.Document, error)
+	OpenFile(actor Actor, id uint) (*entity.Document, io.ReadCloser, error)
+	SetExpiry(actor Actor, id uint, expiresAt *time.Time) (*entity.Document, error)
 }
 
 type documentService struct {
-	docRepo    repository.DocumentRepository
-	folderRepo repository.FolderRepository
+	docRepo       repository.DocumentRepository
+	versionRepo   repository.DocumentVersionRepository
+	transactor    repository.Transactor
+	blobStore     storage.BlobStore
+	dedup         config.DeduplicationConfig
+	locationRepo  repository.FolderLocationRepository
+	containerRepo repository.ContainerRepository
 }
 
-func NewDocumentService(docRepo repository.DocumentRepository, folderRepo repository.FolderRepository) DocumentService {
-	return &documentService{docRepo: docRepo, folderRepo: folderRepo}
+func NewDocumentService(docRepo repository.DocumentRepository, versionRepo repository.DocumentVersionRepository, locationRepo repository.FolderLocationRepository, containerRepo repository.ContainerRepository, transactor repository.Transactor, blobStore storage.BlobStore, dedup config.DeduplicationConfig) DocumentService {
+	return &documentService{docRepo: docRepo, versionRepo: versionRepo, locationRepo: locationRepo, containerRepo: containerRepo, transactor: transactor, blobStore: blobStore, dedup: dedup}
 }
 
-func (s *documentService) CreateDocument(title string, sheetsCount int, folderID *uint, docTypeID uint) (*entity.Document, error) {
+// CreateDocument files a new document. fileHash is the optional SHA-256 of the scan
+// that is going to be attached; when given, duplicates are checked up front so the
+// client learns about them before uploading the file. Filing into a checked-out
+// folder fails unless overrideCheckout is set.
+func (s *documentService) CreateDocument(actor Actor, title string, sheetsCount int, folderID *uint, docTypeID uint, fileHash string, overrideCheckout bool) (*entity.Document, error) {
+	var duplicates []uint
+	if fileHash != "" {
+		var err error
+		duplicates, err = s.checkDuplicates(s.docRepo, actor, docTypeID, fileHash, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	document := &entity.Document{
 		Title:          title,
 		SheetsCount:    sheetsCount,
 		FolderID:       folderID,
 		DocumentTypeID: docTypeID,
+		CreatedBy:      actor.UserID,
 	}
 
-	// If folder is specified, check capacity
-	if folderID != nil {
-		folder, err := s.folderRepo.GetFolderByID(*folderID)
-		if err != nil {
-			return nil, errors.New("folder not found")
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		// If folder is specified, reserve space in it
+		if folderID != nil {
+			if err := reserveSheets(tx, actor, *folderID, sheetsCount); err != nil {
+				return err
+			}
+			if err := checkFolderOnShelf(tx, *folderID, overrideCheckout); err != nil {
+				return err
+			}
 		}
-
-		if (folder.TotalSheets - folder.UsedSheets) < sheetsCount {
-			return nil, errors.New("not enough space in the folder")
+		if err := tx.CreateDocument(document); err != nil {
+			return err
 		}
-
-		// Reserve space in the folder
-		folder.UsedSheets += sheetsCount
-		err = s.folderRepo.UpdateFolder(folder)
-		if err != nil {
-			return nil, errors.New("failed to update folder capacity")
+		if err := recordAudit(tx, actor, entity.AuditActionCreate, entity.AuditEntityDocument, document.ID, nil, document); err != nil {
+			return err
 		}
+		return recordVersion(tx, actor, nil, document, nil)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	err := s.docRepo.CreateDocument(document)
+	document.DuplicateOf = duplicates
+	return document, nil
+}
+
+// GetDocument returns the document together with the location of its folder, so
+// the paper original can be fetched from the shelf, and the date its retention
+// period ends.
+func (s *documentService) GetDocument(actor Actor, id uint) (*entity.Document, error) {
+	document, err := s.docRepo.GetDocumentByID(id, actor.scope())
 	if err != nil {
+		return nil, ErrDocumentNotFound
+	}
+	if document.DisposalDate, err = s.docRepo.DocumentDisposalDate(id); err != nil {
 		return nil, err
 	}
-
+	if document.Folder != nil {
+		if document.Location, err = shelfLocation(s.locationRepo, s.containerRepo, document.Folder); err != nil {
+			return nil, err
+		}
+	}
 	return document, nil
 }
 
-func (s *documentService) GetDocument(id uint) (*entity.Document, error) {
-	return s.docRepo.GetDocumentByID(id)
+// SearchDocuments runs a full-text or fuzzy search over the documents the actor can see.
+func (s *documentService) SearchDocuments(actor Actor, filter DocumentSearchFilter) (*DocumentSearchResult, error) {
+	hits, err := s.docRepo.SearchDocuments(filter, actor.scope())
+	if err != nil {
+		return nil, err
+	}
+	result := &DocumentSearchResult{Hits: hits}
+	if len(hits) == 0 && !filter.Fuzzy {
+		if result.Suggestion, err = s.docRepo.SuggestSearchQuery(filter.Query, actor.scope()); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
 }
 
-func (s *documentService) UpdateDocument(id uint, title *string, sheetsCount *int, folderID *uint) (*entity.Document, error) {
-	document, err := s.docRepo.GetDocumentByID(id)
+// UpdateDocument changes title, sheet count and folder of a document. A nil argument
+// leaves the field unchanged; a folderID pointing to 0 takes the document out of its folder.
+// Moving into a checked-out folder fails unless overrideCheckout is set.
+func (s *documentService) UpdateDocument(actor Actor, id uint, title *string, sheetsCount *int, folderID *uint, overrideCheckout bool) (*entity.Document, error) {
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		document, err := tx.LockDocumentByID(id, actor.scopeFor(entity.PermissionFile))
+		if err != nil {
+			return ErrDocumentNotFound
+		}
+		return updateDocument(tx, actor, document, title, sheetsCount, folderID, nil, overrideCheckout)
+	})
 	if err != nil {
-		return nil, errors.New("document not found")
+		return nil, err
 	}
 
+	return s.docRepo.GetDocumentByID(id, actor.scope())
+}
+
+// updateDocument applies a change to a locked document, keeping folder capacity,
+// the audit trail and the version history in step. restoredFrom is set when the
+// change restores an older version.
+func updateDocument(tx repository.Store, actor Actor, document *entity.Document, title *string, sheetsCount *int, folderID *uint, restoredFrom *int, overrideCheckout bool) error {
+	before := *document
 	oldFolderID := document.FolderID
 	oldSheetsCount := document.SheetsCount
 
@@ -78,81 +174,121 @@ func (s *documentService) UpdateDocument(id uint, title *string, sheetsCount *in
 		document.SheetsCount = *sheetsCount
 	}
 
-	if folderID != nil && (oldFolderID == nil || *folderID != *oldFolderID) {
-		// ...free space in the old folder
-		if oldFolderID != nil {
-			oldFolder, err := s.folderRepo.GetFolderByID(*oldFolderID)
-			if err == nil { // If old folder exists, decrement its used sheets
-				oldFolder.UsedSheets -= oldSheetsCount
-				s.folderRepo.UpdateFolder(oldFolder)
-			}
+	newFolderID := oldFolderID
+	if folderID != nil {
+		newFolderID = folderID
+		if *folderID == 0 {
+			newFolderID = nil
 		}
+	}
+
+	if err := moveSheets(tx, actor, oldFolderID, oldSheetsCount, newFolderID, document.SheetsCount, overrideCheckout); err != nil {
+		return err
+	}
+
+	document.FolderID = newFolderID
+	if err := tx.UpdateDocument(document); err != nil {
+		return err
+	}
+	if err := recordAudit(tx, actor, entity.AuditActionUpdate, entity.AuditEntityDocument, document.ID, &before, document); err != nil {
+		return err
+	}
+	return recordVersion(tx, actor, &before, document, restoredFrom)
+}
 
-		// ...and reserve space in the new folder
-		newFolder, err := s.folderRepo.GetFolderByID(*folderID)
+func (s *documentService) DeleteDocument(actor Actor, id uint) error {
+	var fileHash, fileKey string
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		document, err := tx.LockDocumentByID(id, actor.scopeFor(entity.PermissionFile))
 		if err != nil {
-			return nil, errors.New("new folder not found")
+			return ErrDocumentNotFound
 		}
-		sheetsToUse := oldSheetsCount
-		if sheetsCount != nil {
-			sheetsToUse = *sheetsCount
+
+		fileHash = document.FileHash
+		fileKey, err = releaseFile(tx, document)
+		if err != nil {
+			return err
 		}
-		if (newFolder.TotalSheets - newFolder.UsedSheets) < sheetsToUse {
-			return nil, errors.New("not enough space in the new folder")
+
+		// Free up space in its folder
+		if document.FolderID != nil {
+			if err := releaseSheets(tx, *document.FolderID, document.SheetsCount); err != nil {
+				return err
+			}
 		}
-		newFolder.UsedSheets += sheetsToUse
-		err = s.folderRepo.UpdateFolder(newFolder)
-		if err != nil {
-			return nil, errors.New("failed to reserve space in new folder")
-		}
-		document.FolderID = folderID
-	} else if folderID == nil && oldFolderID != nil {
-		// If folder is being removed (set to nil), free space in the old folder
-		oldFolder, err := s.folderRepo.GetFolderByID(*oldFolderID)
-		if err == nil {
-			oldFolder.UsedSheets -= oldSheetsCount
-			s.folderRepo.UpdateFolder(oldFolder)
-		}
-		document.FolderID = nil
-	} else if sheetsCount != nil && oldFolderID != nil {
-		// If only sheet count changed and document is in a folder, adjust the reservation
-		diff := *sheetsCount - oldSheetsCount
-		folder, err := s.folderRepo.GetFolderByID(*oldFolderID)
-		if err != nil {
-			return nil, errors.New("folder not found")
+
+		if err := tx.DeleteDocument(id); err != nil {
+			return err
 		}
-		if (folder.TotalSheets - folder.UsedSheets) < diff {
-			return nil, errors.New("not enough space in the folder for the increase")
+		return recordAudit(tx, actor, entity.AuditActionDelete, entity.AuditEntityDocument, id, document, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	if fileKey != "" {
+		removeUnreferencedBlob(s.transactor, s.blobStore, fileHash, fileKey)
+	}
+	return nil
+}
+
+// moveSheets adjusts folder usage for a document that goes from (oldFolderID, oldSheets)
+// to (newFolderID, newSheets). Either folder may be nil for an unfiled document.
+// Moving into a checked-out folder fails unless overrideCheckout is set.
+func moveSheets(tx repository.Store, actor Actor, oldFolderID *uint, oldSheets int, newFolderID *uint, newSheets int, overrideCheckout bool) error {
+	if sameFolder(oldFolderID, newFolderID) {
+		if newFolderID == nil || newSheets == oldSheets {
+			return nil
 		}
-		folder.UsedSheets += diff
-		err = s.folderRepo.UpdateFolder(folder)
-		if err != nil {
-			return nil, errors.New("failed to adjust folder capacity")
+		// Only the sheet count changed, adjust the reservation
+		if newSheets > oldSheets {
+			return reserveSheets(tx, actor, *newFolderID, newSheets-oldSheets)
 		}
+		return releaseSheets(tx, *newFolderID, oldSheets-newSheets)
 	}
 
-	err = s.docRepo.UpdateDocument(document)
-	if err != nil {
-		return nil, err
+	if oldFolderID != nil {
+		if err := releaseSheets(tx, *oldFolderID, oldSheets); err != nil {
+			return err
+		}
 	}
+	if newFolderID != nil {
+		if err := reserveSheets(tx, actor, *newFolderID, newSheets); err != nil {
+			return err
+		}
+		return checkFolderOnShelf(tx, *newFolderID, overrideCheckout)
+	}
+	return nil
+}
 
-	return document, nil
+func sameFolder(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
 
-func (s *documentService) DeleteDocument(id uint) error {
-	// First, get the document to free up space in its folder
-	document, err := s.docRepo.GetDocumentByID(id)
-	if err != nil {
-		return err
+// reserveSheets takes sheets from a folder the actor may file into.
+func reserveSheets(tx repository.Store, actor Actor, folderID uint, sheets int) error {
+	if _, err := tx.GetFolderByID(folderID, actor.scopeFor(entity.PermissionFile)); err != nil {
+		return ErrFolderNotFound
 	}
 
-	if document.FolderID != nil {
-		folder, err := s.folderRepo.GetFolderByID(*document.FolderID)
-		if err == nil {
-			folder.UsedSheets -= document.SheetsCount
-			s.folderRepo.UpdateFolder(folder)
-		}
+	err := tx.ReserveSheets(folderID, sheets)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrFolderNotFound
+	case errors.Is(err, repository.ErrNotEnoughSpace):
+		return ErrNotEnoughSpace
 	}
+	return err
+}
 
-	return s.docRepo.DeleteDocument(id)
+func releaseSheets(tx repository.Store, folderID uint, sheets int) error {
+	err := tx.ReleaseSheets(folderID, sheets)
+	// A folder that is already gone has nothing left to free
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	return err
 }
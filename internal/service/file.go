@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+	"io"
+	"log"
+	"net/http"
+)
+
+var ErrNoFile = errors.New("document has no file attached")
+
+// AttachFile attaches the content read from r to the document, replacing any
+// previous file. Content is stored once per SHA-256 and shared between documents;
+// r is read twice, first to hash it and then to upload it before the transaction.
+// Identical files of other documents are handled by the duplicate policy of the
+// document type and reported in DuplicateOf.
+func (s *documentService) AttachFile(actor Actor, id uint, fileName, contentType string, r io.ReadSeeker) (*entity.Document, error) {
+	return s.AttachFileWith(actor, id, fileName, contentType, r, nil)
+}
+
+// AttachFileWith is AttachFile with a step of the caller's that runs first in the
+// attaching transaction: the attach happens only if with succeeds, and anything
+// with writes is rolled back if the attach fails.
+func (s *documentService) AttachFileWith(actor Actor, id uint, fileName, contentType string, r io.ReadSeeker, with func(tx repository.Store) error) (*entity.Document, error) {
+	if _, err := s.docRepo.GetDocumentByID(id, actor.scopeFor(entity.PermissionFile)); err != nil {
+		return nil, ErrDocumentNotFound
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	fileHash := hex.EncodeToString(hash.Sum(nil))
+	contentType, content := detectContentType(contentType, r)
+
+	stagedKey, err := stageBlob(s.blobStore, fileHash, content, size, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicates []uint
+	var keptKey string
+	var oldHash, oldKey string
+	err = s.transactor.Transaction(func(tx repository.Store) error {
+		if with != nil {
+			if err := with(tx); err != nil {
+				return err
+			}
+		}
+
+		document, err := tx.LockDocumentByID(id, actor.scopeFor(entity.PermissionFile))
+		if err != nil {
+			return ErrDocumentNotFound
+		}
+
+		duplicates, err = s.checkDuplicates(tx, actor, document.DocumentTypeID, fileHash, id)
+		if err != nil {
+			return err
+		}
+
+		blob, err := acquireBlob(tx, fileHash, size, contentType, stagedKey)
+		if err != nil {
+			return err
+		}
+		keptKey = blob.Key
+		oldHash = document.FileHash
+		oldKey, err = releaseFile(tx, document)
+		if err != nil {
+			return err
+		}
+
+		before := *document
+		document.FileName = fileName
+		document.FileSize = size
+		document.FileHash = fileHash
+		document.FileMIMEType = contentType
+		document.FileKey = blob.Key
+
+		if err := tx.UpdateDocument(document); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionUpdate, entity.AuditEntityDocument, id, &before, document)
+	})
+	// The staged copy is only needed if it became the blob of committed new content
+	if err != nil || keptKey != stagedKey {
+		if err := s.blobStore.Delete(stagedKey); err != nil {
+			log.Printf("Failed to delete staged file %s: %v", stagedKey, err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if oldKey != "" {
+		removeUnreferencedBlob(s.transactor, s.blobStore, oldHash, oldKey)
+	}
+	document, err := s.docRepo.GetDocumentByID(id, actor.scope())
+	if err != nil {
+		return nil, err
+	}
+	document.DuplicateOf = duplicates
+	return document, nil
+}
+
+// OpenFile returns the document and a reader for its attached file. The caller
+// must close the reader.
+func (s *documentService) OpenFile(actor Actor, id uint) (*entity.Document, io.ReadCloser, error) {
+	document, err := s.docRepo.GetDocumentByID(id, actor.scope())
+	if err != nil {
+		return nil, nil, ErrDocumentNotFound
+	}
+	if document.FileKey == "" {
+		return nil, nil, ErrNoFile
+	}
+
+	content, err := s.blobStore.Get(document.FileKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return document, content, nil
+}
+
+// detectContentType keeps a specific declared MIME type and sniffs the content
+// otherwise. The returned reader still yields the full content.
+func detectContentType(declared string, r io.Reader) (string, io.Reader) {
+	if declared != "" && declared != "application/octet-stream" {
+		return declared, r
+	}
+	br := bufio.NewReaderSize(r, 512)
+	head, _ := br.Peek(512)
+	return http.DetectContentType(head), br
+}
@@ -1,28 +1,473 @@
 package service
 
 import (
+	"errors"
+	"folder-system/internal/config"
 	"folder-system/internal/entity"
 	"folder-system/internal/repository"
+	"folder-system/internal/storage"
+	"math"
 )
 
+// DefaultFolderCapacity is the number of sheets a folder holds when no capacity is given.
+const DefaultFolderCapacity = 480
+
+// FolderDeletePolicy decides what happens to documents still filed in a folder being deleted.
+type FolderDeletePolicy string
+
+const (
+	// DeleteRestrict refuses to delete a folder that still holds documents.
+	DeleteRestrict FolderDeletePolicy = "restrict"
+	// DeleteDetach keeps the documents but leaves them unfiled.
+	DeleteDetach FolderDeletePolicy = "detach"
+	// DeleteCascade deletes the documents together with the folder.
+	DeleteCascade FolderDeletePolicy = "cascade"
+)
+
+var (
+	ErrFolderNotFound      = errors.New("folder not found")
+	ErrFolderNotEmpty      = errors.New("folder still contains documents")
+	ErrInvalidDeletePolicy = errors.New("unknown delete policy")
+	ErrCapacityBelowUsage  = errors.New("total sheets cannot be less than used sheets")
+	ErrNoSuitableFolder    = errors.New("no suitable folder found")
+	ErrInvalidGrant        = errors.New("grant needs exactly one of user_id or group_id and a valid permission")
+	ErrGrantNotFound       = errors.New("folder grant not found")
+)
+
+// FolderListFilter narrows down and orders a folder listing.
+type FolderListFilter = repository.FolderListFilter
+
+// FolderSummary is a folder with its fill level.
+type FolderSummary struct {
+	entity.Folder
+	FreeSheets    int     `json:"free_sheets"`
+	FillPercent   float64 `json:"fill_percent"`
+	DocumentCount int64   `json:"document_count"`
+}
+
 type FolderService interface {
-	GetRecommendedFolder(documentTypeID uint, sheetsCount int) (*entity.Folder, error)
+	CreateFolder(actor Actor, name string, totalSheets int, folderTypeID uint) (*entity.Folder, error)
+	GetFolder(actor Actor, id uint) (*entity.Folder, error)
+	ListFolders(actor Actor, filter FolderListFilter) ([]FolderSummary, error)
+	UpdateFolder(actor Actor, id uint, name *string, totalSheets *int, folderTypeID *uint) (*entity.Folder, error)
+	DeleteFolder(actor Actor, id uint, policy FolderDeletePolicy) error
+	GetRecommendedFolder(actor Actor, documentTypeID uint, sheetsCount int, strategy string, within *uint) (*entity.Folder, error)
+	MoveFolder(actor Actor, id uint, containerID *uint) (*entity.Folder, error)
+	GetFolderLocation(actor Actor, id uint) (*Location, error)
+	AssignFolderLocation(actor Actor, id uint, address LocationAddress) (*entity.FolderLocation, error)
+	ListFolderLocations(actor Actor, id uint) ([]entity.FolderLocation, error)
+	ShareFolder(actor Actor, folderID uint, userID, groupID *uint, permission string) (*entity.FolderGrant, error)
+	ListFolderGrants(actor Actor, folderID uint) ([]entity.FolderGrant, error)
+	RevokeFolderGrant(actor Actor, folderID, grantID uint) error
 }
 
 type folderService struct {
-	folderRepo repository.FolderRepository
+	folderRepo     repository.FolderRepository
+	containerRepo  repository.ContainerRepository
+	locationRepo   repository.FolderLocationRepository
+	assignmentRepo repository.FolderTypeAssignmentRepository
+	grantRepo      repository.FolderGrantRepository
+	transactor     repository.Transactor
+	placement      config.PlacementConfig
+	blobStore      storage.BlobStore
 }
 
-func NewFolderService(folderRepo repository.FolderRepository) FolderService {
-	return &folderService{folderRepo: folderRepo}
+func NewFolderService(folderRepo repository.FolderRepository, containerRepo repository.ContainerRepository, locationRepo repository.FolderLocationRepository, assignmentRepo repository.FolderTypeAssignmentRepository, grantRepo repository.FolderGrantRepository, transactor repository.Transactor, placement config.PlacementConfig, blobStore storage.BlobStore) FolderService {
+	return &folderService{folderRepo: folderRepo, containerRepo: containerRepo, locationRepo: locationRepo, assignmentRepo: assignmentRepo, grantRepo: grantRepo, transactor: transactor, placement: placement, blobStore: blobStore}
 }
 
-func (s *folderService) GetRecommendedFolder(documentTypeID uint, sheetsCount int) (*entity.Folder, error) {
-	folderTypeID := documentTypeID
+func (s *folderService) CreateFolder(actor Actor, name string, totalSheets int, folderTypeID uint) (*entity.Folder, error) {
+	if totalSheets == 0 {
+		totalSheets = DefaultFolderCapacity
+	}
+	// Only admins decide on non-standard capacity
+	if totalSheets != DefaultFolderCapacity && !actor.IsAdmin() {
+		return nil, ErrForbidden
+	}
 
-	folder, err := s.folderRepo.FindFolderByTypeAndCapacity(folderTypeID, sheetsCount)
+	folder := &entity.Folder{
+		Name:         name,
+		TotalSheets:  totalSheets,
+		FolderTypeID: folderTypeID,
+		CreatedBy:    actor.UserID,
+	}
+
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		if err := tx.CreateFolder(folder); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionCreate, entity.AuditEntityFolder, folder.ID, nil, folder)
+	})
 	if err != nil {
-		return nil, err // "not found" is a valid outcome here, not necessarily an error
+		return nil, err
 	}
+
 	return folder, nil
 }
+
+func (s *folderService) GetFolder(actor Actor, id uint) (*entity.Folder, error) {
+	folder, err := s.folderRepo.GetFolderByID(id, actor.scope())
+	if err != nil {
+		return nil, ErrFolderNotFound
+	}
+	return folder, nil
+}
+
+func (s *folderService) ListFolders(actor Actor, filter FolderListFilter) ([]FolderSummary, error) {
+	switch filter.SortBy {
+	case "", repository.FolderSortID, repository.FolderSortName, repository.FolderSortFill, repository.FolderSortFreeSheets:
+	default:
+		return nil, ErrInvalidSortKey
+	}
+
+	folders, err := s.folderRepo.ListFolders(filter, actor.scope())
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(folders))
+	for i, folder := range folders {
+		ids[i] = folder.ID
+	}
+	counts, err := s.folderRepo.CountDocumentsByFolder(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]FolderSummary, len(folders))
+	for i, folder := range folders {
+		summaries[i] = FolderSummary{
+			Folder:        folder,
+			FreeSheets:    folder.TotalSheets - folder.UsedSheets,
+			FillPercent:   fillPercent(folder.UsedSheets, folder.TotalSheets),
+			DocumentCount: counts[folder.ID],
+		}
+	}
+	return summaries, nil
+}
+
+// fillPercent is the share of used sheets rounded to one decimal. A folder without
+// capacity counts as full.
+func fillPercent(used, total int) float64 {
+	if total <= 0 {
+		return 100
+	}
+	return math.Round(float64(used)*1000/float64(total)) / 10
+}
+
+func (s *folderService) UpdateFolder(actor Actor, id uint, name *string, totalSheets *int, folderTypeID *uint) (*entity.Folder, error) {
+	if totalSheets != nil && !actor.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		// The row lock keeps concurrent reservations from slipping in between the
+		// capacity check and the save
+		folder, err := tx.LockFolderByID(id, actor.scopeFor(entity.PermissionManage))
+		if err != nil {
+			return ErrFolderNotFound
+		}
+		before := *folder
+
+		if name != nil {
+			folder.Name = *name
+		}
+		if totalSheets != nil {
+			// Shrinking a folder below what is already filed would break capacity accounting
+			if *totalSheets < folder.UsedSheets {
+				return ErrCapacityBelowUsage
+			}
+			folder.TotalSheets = *totalSheets
+		}
+		if folderTypeID != nil {
+			folder.FolderTypeID = *folderTypeID
+		}
+
+		if err := tx.UpdateFolder(folder); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionUpdate, entity.AuditEntityFolder, folder.ID, &before, folder)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.folderRepo.GetFolderByID(id, actor.scope())
+}
+
+func (s *folderService) DeleteFolder(actor Actor, id uint, policy FolderDeletePolicy) error {
+	switch policy {
+	case DeleteRestrict, DeleteDetach, DeleteCascade:
+	default:
+		return ErrInvalidDeletePolicy
+	}
+
+	// hash -> key of file contents no longer referenced after a cascade delete
+	unreferenced := make(map[string]string)
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		folder, err := tx.LockFolderByID(id, actor.scopeFor(entity.PermissionManage))
+		if err != nil {
+			return ErrFolderNotFound
+		}
+
+		documents, err := tx.ListDocumentsInFolder(id)
+		if err != nil {
+			return err
+		}
+
+		if len(documents) > 0 {
+			switch policy {
+			case DeleteRestrict:
+				return ErrFolderNotEmpty
+			case DeleteDetach:
+				if err := tx.UnfileDocumentsInFolder(id); err != nil {
+					return err
+				}
+				for i := range documents {
+					before := documents[i]
+					documents[i].FolderID = nil
+					if err := recordAudit(tx, actor, entity.AuditActionUpdate, entity.AuditEntityDocument, documents[i].ID, &before, &documents[i]); err != nil {
+						return err
+					}
+					if err := recordVersion(tx, actor, &before, &documents[i], nil); err != nil {
+						return err
+					}
+				}
+			case DeleteCascade:
+				for i := range documents {
+					key, err := releaseFile(tx, &documents[i])
+					if err != nil {
+						return err
+					}
+					if key != "" {
+						unreferenced[documents[i].FileHash] = key
+					}
+					if err := recordAudit(tx, actor, entity.AuditActionDelete, entity.AuditEntityDocument, documents[i].ID, &documents[i], nil); err != nil {
+						return err
+					}
+				}
+				if err := tx.DeleteDocumentsInFolder(id); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.DeleteFolder(id); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionDelete, entity.AuditEntityFolder, id, folder, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	for hash, key := range unreferenced {
+		removeUnreferencedBlob(s.transactor, s.blobStore, hash, key)
+	}
+	return nil
+}
+
+// GetRecommendedFolder walks the folder types assigned to the document type in
+// priority order and returns the folder picked by the placement strategy from
+// the first type that has room. Only folders the actor may file into and that
+// are not checked out are considered. An explicit strategy name overrides the
+// one configured for the folder type. With within set, folders kept anywhere
+// inside that container (e.g. a room or shelf) are preferred over all others.
+func (s *folderService) GetRecommendedFolder(actor Actor, documentTypeID uint, sheetsCount int, strategy string, within *uint) (*entity.Folder, error) {
+	if strategy != "" {
+		if _, err := GetPlacementStrategy(strategy); err != nil {
+			return nil, err
+		}
+	}
+
+	assignments, err := s.assignmentRepo.ListFolderTypeAssignments(&documentTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if within != nil {
+		containerIDs, err := s.containerRepo.ContainerSubtreeIDs(*within)
+		if err != nil {
+			return nil, err
+		}
+		if len(containerIDs) == 0 {
+			return nil, ErrContainerNotFound
+		}
+		folder, err := s.recommendAmong(actor, assignments, sheetsCount, strategy, containerIDs)
+		if !errors.Is(err, ErrNoSuitableFolder) {
+			return folder, err
+		}
+	}
+	return s.recommendAmong(actor, assignments, sheetsCount, strategy, nil)
+}
+
+// recommendAmong runs the recommendation over folders in the given containers,
+// or over all folders if containerIDs is nil.
+func (s *folderService) recommendAmong(actor Actor, assignments []entity.FolderTypeAssignment, sheetsCount int, strategy string, containerIDs []uint) (*entity.Folder, error) {
+	for _, assignment := range assignments {
+		placement, err := s.strategyFor(assignment.FolderTypeID, strategy)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates, err := s.folderRepo.FindFoldersByTypeAndCapacity(assignment.FolderTypeID, sheetsCount, containerIDs, actor.scopeFor(entity.PermissionFile))
+		if err != nil {
+			return nil, err
+		}
+
+		if folder := placement.Select(candidates, sheetsCount); folder != nil {
+			return folder, nil
+		}
+	}
+
+	// "not found" is a valid outcome here, not necessarily an error
+	return nil, ErrNoSuitableFolder
+}
+
+// MoveFolder puts a folder into a container. A nil or zero containerID takes it
+// out of its container.
+func (s *folderService) MoveFolder(actor Actor, id uint, containerID *uint) (*entity.Folder, error) {
+	if containerID != nil && *containerID == 0 {
+		containerID = nil
+	}
+
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		folder, err := tx.LockFolderByID(id, actor.scopeFor(entity.PermissionManage))
+		if err != nil {
+			return ErrFolderNotFound
+		}
+		before := *folder
+
+		moved := !sameFolder(folder.ContainerID, containerID)
+		if containerID != nil && moved {
+			if err := placeInContainer(tx, *containerID, ""); err != nil {
+				return err
+			}
+		}
+
+		folder.ContainerID = containerID
+		if err := tx.UpdateFolder(folder); err != nil {
+			return err
+		}
+		if moved {
+			if err := recordFolderMove(tx, actor, folder); err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, actor, entity.AuditActionUpdate, entity.AuditEntityFolder, id, &before, folder)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.folderRepo.GetFolderByID(id, actor.scope())
+}
+
+// GetFolderLocation answers "where is this folder?" with the containers it is kept
+// in and its current physical address. A folder outside any container has an
+// empty container path.
+func (s *folderService) GetFolderLocation(actor Actor, id uint) (*Location, error) {
+	folder, err := s.folderRepo.GetFolderByID(id, actor.scope())
+	if err != nil {
+		return nil, ErrFolderNotFound
+	}
+
+	location := newLocation(nil)
+	if folder.ContainerID != nil {
+		path, err := s.containerRepo.ContainerPath(*folder.ContainerID)
+		if err != nil {
+			return nil, err
+		}
+		location = newLocation(path)
+	}
+	if location.Current, err = shelfLocation(s.locationRepo, s.containerRepo, folder); err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+func (s *folderService) strategyFor(folderTypeID uint, requested string) (PlacementStrategy, error) {
+	name := requested
+	if name == "" {
+		name = s.placement.ByFolderType[folderTypeID]
+	}
+	if name == "" {
+		name = s.placement.DefaultStrategy
+	}
+	if name == "" {
+		name = StrategyFirstFit
+	}
+	return GetPlacementStrategy(name)
+}
+
+// ShareFolder grants a user or a group access to a folder. The actor needs manage
+// permission on the folder. Repeating a grant returns the existing one.
+func (s *folderService) ShareFolder(actor Actor, folderID uint, userID, groupID *uint, permission string) (*entity.FolderGrant, error) {
+	if (userID == nil) == (groupID == nil) || !entity.IsValidPermission(permission) {
+		return nil, ErrInvalidGrant
+	}
+
+	if _, err := s.folderRepo.GetFolderByID(folderID, actor.scopeFor(entity.PermissionManage)); err != nil {
+		return nil, ErrFolderNotFound
+	}
+
+	grant := &entity.FolderGrant{
+		FolderID:   folderID,
+		UserID:     userID,
+		GroupID:    groupID,
+		Permission: permission,
+	}
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		if userID != nil {
+			if _, err := tx.GetUserByID(*userID); err != nil {
+				return ErrUserNotFound
+			}
+		}
+		if groupID != nil {
+			if _, err := tx.GetGroupByID(*groupID); err != nil {
+				return ErrGroupNotFound
+			}
+		}
+
+		existing, err := tx.FindFolderGrant(folderID, userID, groupID, permission)
+		if err == nil {
+			grant = existing
+			return nil
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+
+		if err := tx.CreateFolderGrant(grant); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionCreate, entity.AuditEntityFolderGrant, grant.ID, nil, grant)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+func (s *folderService) ListFolderGrants(actor Actor, folderID uint) ([]entity.FolderGrant, error) {
+	if _, err := s.folderRepo.GetFolderByID(folderID, actor.scopeFor(entity.PermissionManage)); err != nil {
+		return nil, ErrFolderNotFound
+	}
+	return s.grantRepo.ListFolderGrants(folderID)
+}
+
+func (s *folderService) RevokeFolderGrant(actor Actor, folderID, grantID uint) error {
+	if _, err := s.folderRepo.GetFolderByID(folderID, actor.scopeFor(entity.PermissionManage)); err != nil {
+		return ErrFolderNotFound
+	}
+
+	return s.transactor.Transaction(func(tx repository.Store) error {
+		grant, err := tx.GetFolderGrant(folderID, grantID)
+		if err != nil {
+			return ErrGrantNotFound
+		}
+		if err := tx.DeleteFolderGrant(folderID, grantID); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionDelete, entity.AuditEntityFolderGrant, grantID, grant, nil)
+	})
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+)
+
+var ErrGroupNotFound = errors.New("group not found")
+
+type GroupService interface {
+	CreateGroup(actor Actor, name string) (*entity.Group, error)
+	ListGroups(actor Actor) ([]entity.Group, error)
+	AddMember(actor Actor, groupID, userID uint) (*entity.Group, error)
+	RemoveMember(actor Actor, groupID, userID uint) error
+}
+
+type groupService struct {
+	groupRepo repository.GroupRepository
+	userRepo  repository.UserRepository
+}
+
+func NewGroupService(groupRepo repository.GroupRepository, userRepo repository.UserRepository) GroupService {
+	return &groupService{groupRepo: groupRepo, userRepo: userRepo}
+}
+
+func (s *groupService) CreateGroup(actor Actor, name string) (*entity.Group, error) {
+	group := &entity.Group{Name: name, CreatedBy: actor.UserID}
+	if err := s.groupRepo.CreateGroup(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *groupService) ListGroups(actor Actor) ([]entity.Group, error) {
+	return s.groupRepo.ListGroupsForUser(actor.UserID)
+}
+
+func (s *groupService) AddMember(actor Actor, groupID, userID uint) (*entity.Group, error) {
+	if _, err := s.managedGroup(actor, groupID); err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.GetUserByID(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if err := s.groupRepo.AddGroupMember(&entity.GroupMember{GroupID: groupID, UserID: userID}); err != nil {
+		return nil, err
+	}
+	return s.groupRepo.GetGroupByID(groupID)
+}
+
+func (s *groupService) RemoveMember(actor Actor, groupID, userID uint) error {
+	if _, err := s.managedGroup(actor, groupID); err != nil {
+		return err
+	}
+	return s.groupRepo.RemoveGroupMember(groupID, userID)
+}
+
+// managedGroup returns the group if the actor created it or is an admin.
+func (s *groupService) managedGroup(actor Actor, groupID uint) (*entity.Group, error) {
+	group, err := s.groupRepo.GetGroupByID(groupID)
+	if err != nil {
+		return nil, ErrGroupNotFound
+	}
+	if group.CreatedBy != actor.UserID && !actor.IsAdmin() {
+		return nil, ErrForbidden
+	}
+	return group, nil
+}
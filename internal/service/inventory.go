@@ -0,0 +1,347 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"folder-system/internal/entity"
+	"folder-system/internal/label"
+	"folder-system/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInventoryNotFound  = errors.New("inventory session not found")
+	ErrInventoryCompleted = errors.New("inventory session is completed")
+)
+
+// Reconciliation actions offered for a discrepancy. Each one makes the database
+// agree with what was found on the shelf.
+const (
+	// InventoryActionMove refiles a document into the folder it was found in.
+	InventoryActionMove = "move"
+	// InventoryActionFile files an unfiled document into the folder it was found in.
+	InventoryActionFile = "file"
+	// InventoryActionUnfile takes a document that was not found out of its folder.
+	InventoryActionUnfile = "unfile"
+)
+
+// InventoryDiscrepancy is a document (or an unknown code) whose scanned place does
+// not match the database. ExpectedFolderID is where the database files it,
+// FoundFolderID where it was scanned. Action is empty when nothing can be fixed
+// automatically, e.g. for unknown codes.
+type InventoryDiscrepancy struct {
+	Code             string `json:"code"`
+	DocumentID       *uint  `json:"document_id,omitempty"`
+	Title            string `json:"title,omitempty"`
+	ExpectedFolderID *uint  `json:"expected_folder_id,omitempty"`
+	FoundFolderID    *uint  `json:"found_folder_id,omitempty"`
+	Action           string `json:"action,omitempty"`
+}
+
+// InventoryFolderSummary counts what was expected and found in a recorded folder.
+type InventoryFolderSummary struct {
+	FolderID   uint      `json:"folder_id"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Expected   int       `json:"expected"`
+	Scanned    int       `json:"scanned"`
+	Matched    int       `json:"matched"`
+}
+
+// InventoryReport compares the recorded folders of a session with the database.
+// Missing documents are filed in a recorded folder but were not scanned anywhere;
+// documents in the wrong folder were scanned in a folder other than their own;
+// unexpected ones were scanned although they are unfiled or unknown.
+type InventoryReport struct {
+	Session     *entity.InventorySession `json:"session"`
+	Folders     []InventoryFolderSummary `json:"folders"`
+	Missing     []InventoryDiscrepancy   `json:"missing"`
+	WrongFolder []InventoryDiscrepancy   `json:"wrong_folder"`
+	Unexpected  []InventoryDiscrepancy   `json:"unexpected"`
+}
+
+type InventoryService interface {
+	StartInventory(actor Actor, note string) (*entity.InventorySession, error)
+	RecordFolder(actor Actor, sessionID, folderID uint, codes []string) (*InventoryReport, error)
+	GetReport(actor Actor, sessionID uint) (*InventoryReport, error)
+	Reconcile(actor Actor, sessionID uint, documentIDs []uint, overrideCheckout bool) (*InventoryReport, error)
+	CompleteInventory(actor Actor, sessionID uint) (*entity.InventorySession, error)
+}
+
+type inventoryService struct {
+	inventoryRepo repository.InventoryRepository
+	transactor    repository.Transactor
+}
+
+func NewInventoryService(inventoryRepo repository.InventoryRepository, transactor repository.Transactor) InventoryService {
+	return &inventoryService{inventoryRepo: inventoryRepo, transactor: transactor}
+}
+
+func (s *inventoryService) StartInventory(actor Actor, note string) (*entity.InventorySession, error) {
+	session := &entity.InventorySession{CreatedBy: actor.UserID, Note: note}
+	if err := s.inventoryRepo.CreateInventorySession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// RecordFolder stores the codes scanned in a folder, replacing anything recorded
+// for it before in the session.
+func (s *inventoryService) RecordFolder(actor Actor, sessionID, folderID uint, codes []string) (*InventoryReport, error) {
+	var report *InventoryReport
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		session, err := lockInventory(tx, actor, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.CompletedAt != nil {
+			return ErrInventoryCompleted
+		}
+		if _, err := tx.GetFolderByID(folderID, actor.scopeFor(entity.PermissionFile)); err != nil {
+			return ErrFolderNotFound
+		}
+
+		seen := make(map[string]bool)
+		var items []entity.InventoryItem
+		for _, code := range codes {
+			item := entity.InventoryItem{SessionID: sessionID, FolderID: folderID, Code: strings.TrimSpace(code)}
+			if kind, id, err := label.ParseCode(code); err == nil && kind == label.KindDocument {
+				item.Code = label.DocumentCode(id)
+				item.DocumentID = &id
+			}
+			// The same label scanned twice is still one document
+			if item.Code == "" || seen[item.Code] {
+				continue
+			}
+			seen[item.Code] = true
+			items = append(items, item)
+		}
+
+		folder := &entity.InventoryFolder{SessionID: sessionID, FolderID: folderID, RecordedBy: actor.UserID, RecordedAt: time.Now()}
+		if err := tx.RecordInventoryFolder(folder, items); err != nil {
+			return err
+		}
+		report, err = buildInventoryReport(tx, actor, session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *inventoryService) GetReport(actor Actor, sessionID uint) (*InventoryReport, error) {
+	var report *InventoryReport
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		session, err := lockInventory(tx, actor, sessionID)
+		if err != nil {
+			return err
+		}
+		report, err = buildInventoryReport(tx, actor, session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Reconcile applies the suggested action of every discrepancy, or only of those
+// for the given documents. Changes go through the usual capacity, permission and
+// check-out rules; if one of them fails nothing is changed.
+func (s *inventoryService) Reconcile(actor Actor, sessionID uint, documentIDs []uint, overrideCheckout bool) (*InventoryReport, error) {
+	selected := make(map[uint]bool, len(documentIDs))
+	for _, id := range documentIDs {
+		selected[id] = true
+	}
+
+	var report *InventoryReport
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		session, err := lockInventory(tx, actor, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.CompletedAt != nil {
+			return ErrInventoryCompleted
+		}
+		report, err = buildInventoryReport(tx, actor, session)
+		if err != nil {
+			return err
+		}
+
+		var discrepancies []InventoryDiscrepancy
+		discrepancies = append(discrepancies, report.Missing...)
+		discrepancies = append(discrepancies, report.WrongFolder...)
+		discrepancies = append(discrepancies, report.Unexpected...)
+		for _, d := range discrepancies {
+			if d.Action == "" || (len(selected) > 0 && !selected[*d.DocumentID]) {
+				continue
+			}
+			if err := reconcileDiscrepancy(tx, actor, d, overrideCheckout); err != nil {
+				return fmt.Errorf("document %d: %w", *d.DocumentID, err)
+			}
+		}
+
+		report, err = buildInventoryReport(tx, actor, session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *inventoryService) CompleteInventory(actor Actor, sessionID uint) (*entity.InventorySession, error) {
+	var session *entity.InventorySession
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		var err error
+		session, err = lockInventory(tx, actor, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.CompletedAt != nil {
+			return ErrInventoryCompleted
+		}
+		now := time.Now()
+		session.CompletedAt = &now
+		return tx.UpdateInventorySession(session)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// lockInventory locks a session that the actor started; admins may use any session.
+func lockInventory(tx repository.Store, actor Actor, id uint) (*entity.InventorySession, error) {
+	session, err := tx.LockInventorySession(id)
+	if err != nil || (session.CreatedBy != actor.UserID && !actor.IsAdmin()) {
+		return nil, ErrInventoryNotFound
+	}
+	return session, nil
+}
+
+func reconcileDiscrepancy(tx repository.Store, actor Actor, d InventoryDiscrepancy, overrideCheckout bool) error {
+	document, err := tx.LockDocumentByID(*d.DocumentID, actor.scopeFor(entity.PermissionFile))
+	if err != nil {
+		return ErrDocumentNotFound
+	}
+
+	folderID := d.FoundFolderID
+	if d.Action == InventoryActionUnfile {
+		unfiled := uint(0)
+		folderID = &unfiled
+	}
+	return updateDocument(tx, actor, document, nil, nil, folderID, nil, overrideCheckout)
+}
+
+func buildInventoryReport(tx repository.Store, actor Actor, session *entity.InventorySession) (*InventoryReport, error) {
+	folders, err := tx.ListInventoryFolders(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := tx.ListInventoryItems(session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &InventoryReport{
+		Session:     session,
+		Folders:     make([]InventoryFolderSummary, 0, len(folders)),
+		Missing:     []InventoryDiscrepancy{},
+		WrongFolder: []InventoryDiscrepancy{},
+		Unexpected:  []InventoryDiscrepancy{},
+	}
+
+	// What the database says is filed in the recorded folders
+	expected := make(map[uint]entity.Document)
+	summaries := make(map[uint]*InventoryFolderSummary, len(folders))
+	for _, folder := range folders {
+		documents, err := tx.ListDocumentsInFolder(folder.FolderID)
+		if err != nil {
+			return nil, err
+		}
+		for _, document := range documents {
+			expected[document.ID] = document
+		}
+		report.Folders = append(report.Folders, InventoryFolderSummary{
+			FolderID:   folder.FolderID,
+			RecordedAt: folder.RecordedAt,
+			Expected:   len(documents),
+		})
+	}
+	for i := range report.Folders {
+		summaries[report.Folders[i].FolderID] = &report.Folders[i]
+	}
+
+	// Scanned documents filed elsewhere or not at all
+	var elsewhereIDs []uint
+	for _, item := range items {
+		if item.DocumentID != nil {
+			if _, ok := expected[*item.DocumentID]; !ok {
+				elsewhereIDs = append(elsewhereIDs, *item.DocumentID)
+			}
+		}
+	}
+	elsewhere, err := tx.ListDocumentsByIDs(elsewhereIDs, actor.scope())
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[uint]entity.Document, len(elsewhere))
+	for _, document := range elsewhere {
+		known[document.ID] = document
+	}
+
+	found := make(map[uint]bool, len(items))
+	for _, item := range items {
+		if summary := summaries[item.FolderID]; summary != nil {
+			summary.Scanned++
+		}
+		foundFolderID := item.FolderID
+		d := InventoryDiscrepancy{Code: item.Code, DocumentID: item.DocumentID, FoundFolderID: &foundFolderID}
+		if item.DocumentID == nil {
+			report.Unexpected = append(report.Unexpected, d)
+			continue
+		}
+		found[*item.DocumentID] = true
+
+		document, ok := expected[*item.DocumentID]
+		if !ok {
+			document, ok = known[*item.DocumentID]
+		}
+		switch {
+		case !ok:
+			// Deleted, or not visible to the actor
+			report.Unexpected = append(report.Unexpected, d)
+		case document.FolderID == nil:
+			d.Title, d.Action = document.Title, InventoryActionFile
+			report.Unexpected = append(report.Unexpected, d)
+		case *document.FolderID == item.FolderID:
+			if summary := summaries[item.FolderID]; summary != nil {
+				summary.Matched++
+			}
+		default:
+			d.Title, d.Action, d.ExpectedFolderID = document.Title, InventoryActionMove, document.FolderID
+			report.WrongFolder = append(report.WrongFolder, d)
+		}
+	}
+
+	for id, document := range expected {
+		if found[id] {
+			continue
+		}
+		documentID := id
+		report.Missing = append(report.Missing, InventoryDiscrepancy{
+			Code:             label.DocumentCode(id),
+			DocumentID:       &documentID,
+			Title:            document.Title,
+			ExpectedFolderID: document.FolderID,
+			Action:           InventoryActionUnfile,
+		})
+	}
+	sort.Slice(report.Missing, func(i, j int) bool {
+		return *report.Missing[i].DocumentID < *report.Missing[j].DocumentID
+	})
+	return report, nil
+}
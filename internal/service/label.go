@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"folder-system/internal/label"
+	"folder-system/internal/repository"
+)
+
+// MaxLabelsPerSheet caps the folders printed on one label sheet.
+const MaxLabelsPerSheet = 100
+
+var ErrTooManyLabels = errors.New("too many labels for one sheet")
+
+type LabelService interface {
+	FolderLabels(actor Actor, ids []uint, symbology string) ([]*label.Label, error)
+	DocumentLabel(actor Actor, id uint, symbology string) (*label.Label, error)
+}
+
+type labelService struct {
+	folderRepo repository.FolderRepository
+	docRepo    repository.DocumentRepository
+}
+
+func NewLabelService(folderRepo repository.FolderRepository, docRepo repository.DocumentRepository) LabelService {
+	return &labelService{folderRepo: folderRepo, docRepo: docRepo}
+}
+
+// FolderLabels returns labels for the given folders in the order asked for.
+func (s *labelService) FolderLabels(actor Actor, ids []uint, symbology string) ([]*label.Label, error) {
+	if len(ids) > MaxLabelsPerSheet {
+		return nil, ErrTooManyLabels
+	}
+	labels := make([]*label.Label, 0, len(ids))
+	for _, id := range ids {
+		if _, err := s.folderRepo.GetFolderByID(id, actor.scope()); err != nil {
+			return nil, ErrFolderNotFound
+		}
+		l, err := label.New(label.FolderCode(id), symbology)
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, l)
+	}
+	return labels, nil
+}
+
+func (s *labelService) DocumentLabel(actor Actor, id uint, symbology string) (*label.Label, error) {
+	if _, err := s.docRepo.GetDocumentByID(id, actor.scope()); err != nil {
+		return nil, ErrDocumentNotFound
+	}
+	return label.New(label.DocumentCode(id), symbology)
+}
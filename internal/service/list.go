@@ -0,0 +1,192 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+	"time"
+)
+
+var (
+	ErrInvalidCursor  = errors.New("invalid cursor")
+	ErrInvalidSortKey = errors.New("unknown sort key")
+)
+
+// DocumentListQuery describes a page of the document list. Cursor is an opaque
+// value taken from the next_cursor or prev_cursor of a previous page and must be
+// used with the same sort.
+type DocumentListQuery struct {
+	FolderID       *uint
+	DocumentTypeID *uint
+	Unfiled        bool
+	From           *time.Time
+	To             *time.Time
+	MinSheets      *int
+	MaxSheets      *int
+	SortBy         string
+	Desc           bool
+	Cursor         string
+	Limit          int
+}
+
+// DocumentPage is one page of the document list. Total counts all matching
+// documents, not just the ones on the page.
+type DocumentPage struct {
+	Documents  []entity.Document `json:"documents"`
+	Total      int64             `json:"total"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	PrevCursor string            `json:"prev_cursor,omitempty"`
+}
+
+// documentCursor is the decoded form of an opaque list cursor.
+type documentCursor struct {
+	SortBy string          `json:"s"`
+	Desc   bool            `json:"d,omitempty"`
+	Before bool            `json:"b,omitempty"`
+	Value  json.RawMessage `json:"v"`
+	ID     uint            `json:"id"`
+}
+
+// ListDocuments returns one page of the documents the actor can see.
+func (s *documentService) ListDocuments(actor Actor, query DocumentListQuery) (*DocumentPage, error) {
+	if query.SortBy == "" {
+		query.SortBy = repository.DocumentSortCreatedAt
+	}
+	switch query.SortBy {
+	case repository.DocumentSortCreatedAt, repository.DocumentSortTitle, repository.DocumentSortSheetsCount, repository.DocumentSortID:
+	default:
+		return nil, ErrInvalidSortKey
+	}
+
+	filter := repository.DocumentListFilter{
+		FolderID:       query.FolderID,
+		DocumentTypeID: query.DocumentTypeID,
+		Unfiled:        query.Unfiled,
+		From:           query.From,
+		To:             query.To,
+		MinSheets:      query.MinSheets,
+		MaxSheets:      query.MaxSheets,
+		SortBy:         query.SortBy,
+		Desc:           query.Desc,
+	}
+
+	backwards := false
+	if query.Cursor != "" {
+		cursor, before, err := decodeDocumentCursor(query.Cursor, query.SortBy, query.Desc)
+		if err != nil {
+			return nil, err
+		}
+		backwards = before
+		if before {
+			filter.Before = cursor
+		} else {
+			filter.After = cursor
+		}
+	}
+
+	total, err := s.docRepo.CountDocuments(filter, actor.scope())
+	if err != nil {
+		return nil, err
+	}
+
+	// One extra document tells whether there is another page in the walking direction
+	filter.Limit = query.Limit + 1
+	documents, err := s.docRepo.ListDocuments(filter, actor.scope())
+	if err != nil {
+		return nil, err
+	}
+	more := len(documents) > query.Limit
+	if more {
+		if backwards {
+			documents = documents[1:]
+		} else {
+			documents = documents[:query.Limit]
+		}
+	}
+
+	page := &DocumentPage{Documents: documents, Total: total}
+	if len(documents) == 0 {
+		return page, nil
+	}
+
+	// Coming from a cursor means there is a page on the side we came from
+	hasNext := more || (query.Cursor != "" && backwards)
+	hasPrev := (more && backwards) || (query.Cursor != "" && !backwards)
+	if hasNext {
+		if page.NextCursor, err = encodeDocumentCursor(documents[len(documents)-1], query.SortBy, query.Desc, false); err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		if page.PrevCursor, err = encodeDocumentCursor(documents[0], query.SortBy, query.Desc, true); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+func encodeDocumentCursor(document entity.Document, sortBy string, desc, before bool) (string, error) {
+	var value interface{}
+	switch sortBy {
+	case repository.DocumentSortCreatedAt:
+		value = document.CreatedAt
+	case repository.DocumentSortTitle:
+		value = document.Title
+	case repository.DocumentSortSheetsCount:
+		value = document.SheetsCount
+	default:
+		value = document.ID
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(documentCursor{SortBy: sortBy, Desc: desc, Before: before, Value: raw, ID: document.ID})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeDocumentCursor parses an opaque cursor and checks that it belongs to the
+// requested sort. It reports whether the cursor pages backwards.
+func decodeDocumentCursor(encoded, sortBy string, desc bool) (*repository.DocumentCursor, bool, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	var cursor documentCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	if cursor.SortBy != sortBy || cursor.Desc != desc {
+		return nil, false, ErrInvalidCursor
+	}
+
+	var value interface{}
+	switch sortBy {
+	case repository.DocumentSortCreatedAt:
+		var t time.Time
+		err = json.Unmarshal(cursor.Value, &t)
+		value = t
+	case repository.DocumentSortTitle:
+		var title string
+		err = json.Unmarshal(cursor.Value, &title)
+		value = title
+	case repository.DocumentSortSheetsCount:
+		var sheets int
+		err = json.Unmarshal(cursor.Value, &sheets)
+		value = sheets
+	default:
+		var id uint
+		err = json.Unmarshal(cursor.Value, &id)
+		value = id
+	}
+	if err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	return &repository.DocumentCursor{Value: value, ID: cursor.ID}, cursor.Before, nil
+}
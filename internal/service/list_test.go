@@ -0,0 +1,87 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+)
+
+func TestDocumentCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 30, 45, 123456000, time.UTC)
+	document := entity.Document{Title: "Lease agreement", SheetsCount: 7}
+	document.ID = 42
+	document.CreatedAt = createdAt
+
+	tests := []struct {
+		sortBy string
+		want   interface{}
+	}{
+		{repository.DocumentSortCreatedAt, createdAt},
+		{repository.DocumentSortTitle, "Lease agreement"},
+		{repository.DocumentSortSheetsCount, 7},
+		{repository.DocumentSortID, uint(42)},
+	}
+	for _, tt := range tests {
+		for _, desc := range []bool{false, true} {
+			for _, before := range []bool{false, true} {
+				encoded, err := encodeDocumentCursor(document, tt.sortBy, desc, before)
+				if err != nil {
+					t.Fatalf("%s: encode: %v", tt.sortBy, err)
+				}
+				cursor, gotBefore, err := decodeDocumentCursor(encoded, tt.sortBy, desc)
+				if err != nil {
+					t.Fatalf("%s desc=%v before=%v: decode: %v", tt.sortBy, desc, before, err)
+				}
+				if gotBefore != before {
+					t.Errorf("%s desc=%v: before = %v, want %v", tt.sortBy, desc, gotBefore, before)
+				}
+				if cursor.ID != document.ID {
+					t.Errorf("%s: id = %d, want %d", tt.sortBy, cursor.ID, document.ID)
+				}
+				if got, ok := cursor.Value.(time.Time); ok {
+					if !got.Equal(createdAt) {
+						t.Errorf("%s: value = %v, want %v", tt.sortBy, got, createdAt)
+					}
+				} else if cursor.Value != tt.want {
+					t.Errorf("%s: value = %#v, want %#v", tt.sortBy, cursor.Value, tt.want)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeDocumentCursorRejectsOtherSort(t *testing.T) {
+	document := entity.Document{Title: "a"}
+	document.ID = 1
+	encoded, err := encodeDocumentCursor(document, repository.DocumentSortTitle, false, false)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if _, _, err := decodeDocumentCursor(encoded, repository.DocumentSortSheetsCount, false); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("other sort key: err = %v, want ErrInvalidCursor", err)
+	}
+	if _, _, err := decodeDocumentCursor(encoded, repository.DocumentSortTitle, true); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("other direction: err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestDecodeDocumentCursorRejectsGarbage(t *testing.T) {
+	tests := []struct {
+		encoded string
+		sortBy  string
+	}{
+		{"not base64!", repository.DocumentSortID},
+		{base64.RawURLEncoding.EncodeToString([]byte("not json")), repository.DocumentSortID},
+		{base64.RawURLEncoding.EncodeToString([]byte(`{"s":"sheets_count","v":"seven","id":1}`)), repository.DocumentSortSheetsCount},
+		{base64.RawURLEncoding.EncodeToString([]byte(`{"s":"created_at","v":12,"id":1}`)), repository.DocumentSortCreatedAt},
+	}
+	for _, tt := range tests {
+		if _, _, err := decodeDocumentCursor(tt.encoded, tt.sortBy, false); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decode %q: err = %v, want ErrInvalidCursor", tt.encoded, err)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"errors"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+	"time"
+)
+
+var (
+	ErrLoanNotFound      = errors.New("loan not found")
+	ErrInvalidLoan       = errors.New("loan needs exactly one of folder_id or document_id and a due date in the future")
+	ErrAlreadyCheckedOut = errors.New("item is already checked out")
+	ErrLoanReturned      = errors.New("loan has already been returned")
+	ErrFolderCheckedOut  = errors.New("folder is checked out; set override_checkout to file into it anyway")
+	ErrDocumentsOnLoan   = errors.New("documents of the folder are checked out; check them in first")
+)
+
+// LoanFilter narrows down a loan listing.
+type LoanFilter = repository.LoanFilter
+
+type LoanService interface {
+	CheckOut(actor Actor, folderID, documentID *uint, borrowerID uint, dueAt time.Time, note string) (*entity.Loan, error)
+	CheckIn(actor Actor, id uint) (*entity.Loan, error)
+	ListLoans(actor Actor, filter LoanFilter) ([]entity.Loan, error)
+}
+
+type loanService struct {
+	loanRepo   repository.LoanRepository
+	transactor repository.Transactor
+}
+
+func NewLoanService(loanRepo repository.LoanRepository, transactor repository.Transactor) LoanService {
+	return &loanService{loanRepo: loanRepo, transactor: transactor}
+}
+
+// CheckOut lends a folder or a single document to a user until dueAt. A zero
+// borrowerID lends it to the actor. A document cannot be lent on its own while
+// its folder is checked out, nor a folder while some of its documents are.
+func (s *loanService) CheckOut(actor Actor, folderID, documentID *uint, borrowerID uint, dueAt time.Time, note string) (*entity.Loan, error) {
+	if (folderID == nil) == (documentID == nil) || !dueAt.After(time.Now()) {
+		return nil, ErrInvalidLoan
+	}
+	if borrowerID == 0 {
+		borrowerID = actor.UserID
+	}
+
+	loan := &entity.Loan{
+		FolderID:   folderID,
+		DocumentID: documentID,
+		BorrowerID: borrowerID,
+		LentBy:     actor.UserID,
+		DueAt:      dueAt,
+		Note:       note,
+	}
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		if _, err := tx.GetUserByID(borrowerID); err != nil {
+			return ErrUserNotFound
+		}
+
+		// Both kinds of check-out lock the folder first, which serializes a folder
+		// loan against loans of its documents
+		var active *entity.Loan
+		if folderID != nil {
+			if _, err := tx.LockFolderByID(*folderID, actor.scopeFor(entity.PermissionFile)); err != nil {
+				return ErrFolderNotFound
+			}
+			lent, err := tx.CountActiveDocumentLoansInFolder(*folderID)
+			if err != nil {
+				return err
+			}
+			if lent > 0 {
+				return ErrDocumentsOnLoan
+			}
+			active, err = tx.ActiveFolderLoan(*folderID)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+		} else {
+			var err error
+			if active, err = s.lockDocumentForLoan(tx, actor, *documentID); err != nil {
+				return err
+			}
+		}
+		if active != nil {
+			return ErrAlreadyCheckedOut
+		}
+
+		if err := tx.CreateLoan(loan); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionCreate, entity.AuditEntityLoan, loan.ID, nil, loan)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loan, nil
+}
+
+// lockDocumentForLoan locks the document and the folder it is filed in, folder
+// first, and returns the document's active loan, if any. A document whose folder
+// is checked out counts as checked out.
+func (s *loanService) lockDocumentForLoan(tx repository.Store, actor Actor, documentID uint) (*entity.Loan, error) {
+	document, err := tx.GetDocumentByID(documentID, actor.scopeFor(entity.PermissionFile))
+	if err != nil {
+		return nil, ErrDocumentNotFound
+	}
+	// The document already grants access, whoever owns the folder
+	lockFolder := func(folderID *uint) error {
+		if folderID == nil {
+			return nil
+		}
+		_, err := tx.LockFolderByID(*folderID, repository.AccessScope{Unrestricted: true})
+		return err
+	}
+	if err := lockFolder(document.FolderID); err != nil {
+		return nil, err
+	}
+	locked, err := tx.LockDocumentByID(documentID, actor.scopeFor(entity.PermissionFile))
+	if err != nil {
+		return nil, ErrDocumentNotFound
+	}
+	// Moved in the meantime: hold its new folder as well
+	if locked.FolderID != nil && (document.FolderID == nil || *locked.FolderID != *document.FolderID) {
+		if err := lockFolder(locked.FolderID); err != nil {
+			return nil, err
+		}
+	}
+
+	if locked.FolderID != nil {
+		if err := checkFolderOnShelf(tx, *locked.FolderID, false); err != nil {
+			if errors.Is(err, ErrFolderCheckedOut) {
+				return nil, ErrAlreadyCheckedOut
+			}
+			return nil, err
+		}
+	}
+	active, err := tx.ActiveDocumentLoan(documentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	return active, err
+}
+
+// CheckIn records that a lent item is back. Anyone who may file into the item
+// can take it back, not only the borrower.
+func (s *loanService) CheckIn(actor Actor, id uint) (*entity.Loan, error) {
+	var loan *entity.Loan
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		var err error
+		loan, err = tx.LockLoan(id)
+		if err != nil {
+			return ErrLoanNotFound
+		}
+		if loan.ReturnedAt != nil {
+			return ErrLoanReturned
+		}
+		if err := checkLoanAccess(tx, actor, loan); err != nil {
+			return err
+		}
+
+		before := *loan
+		now := time.Now()
+		loan.ReturnedAt = &now
+		loan.ReturnedTo = &actor.UserID
+		if err := tx.UpdateLoan(loan); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionUpdate, entity.AuditEntityLoan, id, &before, loan)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loan, nil
+}
+
+// ListLoans returns loans matching the filter. Admins see all loans, everybody
+// else the loans they borrowed or handed out.
+func (s *loanService) ListLoans(actor Actor, filter LoanFilter) ([]entity.Loan, error) {
+	if !actor.IsAdmin() {
+		filter.UserID = &actor.UserID
+	}
+	loans, err := s.loanRepo.ListLoans(filter)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range loans {
+		loans[i].Overdue = loans[i].IsOverdue(now)
+	}
+	return loans, nil
+}
+
+func checkLoanAccess(tx repository.Store, actor Actor, loan *entity.Loan) error {
+	if loan.FolderID != nil {
+		if _, err := tx.GetFolderByID(*loan.FolderID, actor.scopeFor(entity.PermissionFile)); err != nil {
+			return ErrLoanNotFound
+		}
+		return nil
+	}
+	if _, err := tx.GetDocumentByID(*loan.DocumentID, actor.scopeFor(entity.PermissionFile)); err != nil {
+		return ErrLoanNotFound
+	}
+	return nil
+}
+
+// checkFolderOnShelf fails with ErrFolderCheckedOut if the folder is lent out,
+// unless override is set. Callers run it after reserving sheets, when the folder
+// row is locked, so a concurrent check-out is already visible.
+func checkFolderOnShelf(tx repository.Store, folderID uint, override bool) error {
+	if override {
+		return nil
+	}
+	_, err := tx.ActiveFolderLoan(folderID)
+	if err == nil {
+		return ErrFolderCheckedOut
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+	"strings"
+)
+
+// LocationAddress is a physical address of a folder entered by hand, e.g. for
+// sites that are not modelled as containers or a position on a shelf.
+type LocationAddress struct {
+	Site     string
+	Room     string
+	Rack     string
+	Shelf    string
+	Position string
+	Note     string
+}
+
+// AssignFolderLocation records where a folder has been put. The folder stays in
+// its container; the entry is appended to the folder's location history.
+func (s *folderService) AssignFolderLocation(actor Actor, id uint, address LocationAddress) (*entity.FolderLocation, error) {
+	location := &entity.FolderLocation{
+		FolderID: id,
+		Site:     strings.TrimSpace(address.Site),
+		Room:     strings.TrimSpace(address.Room),
+		Rack:     strings.TrimSpace(address.Rack),
+		Shelf:    strings.TrimSpace(address.Shelf),
+		Position: strings.TrimSpace(address.Position),
+		Note:     address.Note,
+		MovedBy:  actor.UserID,
+	}
+
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		folder, err := tx.LockFolderByID(id, actor.scopeFor(entity.PermissionManage))
+		if err != nil {
+			return ErrFolderNotFound
+		}
+		location.ContainerID = folder.ContainerID
+
+		if err := tx.CreateFolderLocation(location); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionCreate, entity.AuditEntityFolderLocation, location.ID, nil, location)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+// ListFolderLocations returns where the folder has been kept over time, newest first.
+func (s *folderService) ListFolderLocations(actor Actor, id uint) ([]entity.FolderLocation, error) {
+	if _, err := s.folderRepo.GetFolderByID(id, actor.scope()); err != nil {
+		return nil, ErrFolderNotFound
+	}
+	return s.locationRepo.ListFolderLocations(id)
+}
+
+// recordFolderMove appends a location history entry for a folder that has just
+// been put into a container, taking the address from the container path. The
+// site is kept from the previous entry since sites are not containers.
+func recordFolderMove(tx repository.Store, actor Actor, folder *entity.Folder) error {
+	location := &entity.FolderLocation{
+		FolderID:    folder.ID,
+		ContainerID: folder.ContainerID,
+		MovedBy:     actor.UserID,
+	}
+
+	previous, err := tx.CurrentFolderLocation(folder.ID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	if previous != nil {
+		location.Site = previous.Site
+	}
+
+	if folder.ContainerID != nil {
+		path, err := tx.ContainerPath(*folder.ContainerID)
+		if err != nil {
+			return err
+		}
+		addressFromPath(location, path)
+	}
+
+	if err := tx.CreateFolderLocation(location); err != nil {
+		return err
+	}
+	return recordAudit(tx, actor, entity.AuditActionCreate, entity.AuditEntityFolderLocation, location.ID, nil, location)
+}
+
+// shelfLocation tells where a folder can be found: its newest location entry with
+// the container path as it is now. It returns nil for a folder that was never placed.
+func shelfLocation(locationRepo repository.FolderLocationRepository, containerRepo repository.ContainerRepository, folder *entity.Folder) (*entity.FolderLocation, error) {
+	location, err := locationRepo.CurrentFolderLocation(folder.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		if folder.ContainerID == nil {
+			return nil, nil
+		}
+		// Placed in a container before location history was kept
+		location = &entity.FolderLocation{FolderID: folder.ID, ContainerID: folder.ContainerID}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if location.ContainerID != nil {
+		path, err := containerRepo.ContainerPath(*location.ContainerID)
+		if err != nil {
+			return nil, err
+		}
+		location.Path = newLocation(path).Path
+		if location.Room == "" && location.Rack == "" && location.Shelf == "" && location.Position == "" {
+			addressFromPath(location, path)
+		}
+	}
+	return location, nil
+}
+
+// addressFromPath fills the address fields from the kinds of the containers on
+// the path: the room, the cabinet as rack, the shelf and the box as position.
+func addressFromPath(location *entity.FolderLocation, path []entity.Container) {
+	for _, container := range path {
+		switch container.Kind {
+		case entity.ContainerRoom:
+			location.Room = container.Name
+		case entity.ContainerCabinet:
+			location.Rack = container.Name
+		case entity.ContainerShelf:
+			location.Shelf = container.Name
+		case entity.ContainerBox:
+			location.Position = container.Name
+		}
+	}
+}
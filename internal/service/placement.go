@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"folder-system/internal/entity"
+)
+
+// Names of the built-in placement strategies, as used in config and in the
+// "strategy" query parameter of the recommendation endpoint.
+const (
+	StrategyFirstFit   = "first-fit"
+	StrategyBestFit    = "best-fit"
+	StrategyWorstFit   = "worst-fit"
+	StrategyOldestOpen = "oldest-open"
+)
+
+var ErrUnknownStrategy = errors.New("unknown placement strategy")
+
+// PlacementStrategy picks the folder a document should be filed into.
+// Candidates are folders of one type that already have enough free space,
+// ordered by ID; Select returns nil when none of them is acceptable.
+type PlacementStrategy interface {
+	Name() string
+	Select(candidates []entity.Folder, sheetsCount int) *entity.Folder
+}
+
+var placementStrategies = map[string]PlacementStrategy{
+	StrategyFirstFit:   firstFit{},
+	StrategyBestFit:    bestFit{},
+	StrategyWorstFit:   worstFit{},
+	StrategyOldestOpen: oldestOpen{},
+}
+
+// GetPlacementStrategy returns the built-in strategy registered under name.
+func GetPlacementStrategy(name string) (PlacementStrategy, error) {
+	strategy, ok := placementStrategies[name]
+	if !ok {
+		return nil, ErrUnknownStrategy
+	}
+	return strategy, nil
+}
+
+func freeSheets(folder *entity.Folder) int {
+	return folder.TotalSheets - folder.UsedSheets
+}
+
+// firstFit takes the first folder that fits.
+type firstFit struct{}
+
+func (firstFit) Name() string { return StrategyFirstFit }
+
+func (firstFit) Select(candidates []entity.Folder, sheetsCount int) *entity.Folder {
+	if len(candidates) == 0 {
+		return nil
+	}
+	return &candidates[0]
+}
+
+// bestFit takes the folder that is left with the least free space, which keeps
+// other folders open for larger documents.
+type bestFit struct{}
+
+func (bestFit) Name() string { return StrategyBestFit }
+
+func (bestFit) Select(candidates []entity.Folder, sheetsCount int) *entity.Folder {
+	var best *entity.Folder
+	for i := range candidates {
+		if best == nil || freeSheets(&candidates[i]) < freeSheets(best) {
+			best = &candidates[i]
+		}
+	}
+	return best
+}
+
+// worstFit takes the folder with the most free space.
+type worstFit struct{}
+
+func (worstFit) Name() string { return StrategyWorstFit }
+
+func (worstFit) Select(candidates []entity.Folder, sheetsCount int) *entity.Folder {
+	var worst *entity.Folder
+	for i := range candidates {
+		if worst == nil || freeSheets(&candidates[i]) > freeSheets(worst) {
+			worst = &candidates[i]
+		}
+	}
+	return worst
+}
+
+// oldestOpen keeps filling the oldest folder that is already in use and only
+// starts an empty folder once no open one fits.
+type oldestOpen struct{}
+
+func (oldestOpen) Name() string { return StrategyOldestOpen }
+
+func (oldestOpen) Select(candidates []entity.Folder, sheetsCount int) *entity.Folder {
+	var open, empty *entity.Folder
+	for i := range candidates {
+		folder := &candidates[i]
+		if folder.UsedSheets > 0 {
+			if open == nil || folder.CreatedAt.Before(open.CreatedAt) {
+				open = folder
+			}
+		} else if empty == nil || folder.CreatedAt.Before(empty.CreatedAt) {
+			empty = folder
+		}
+	}
+	if open != nil {
+		return open
+	}
+	return empty
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+	"folder-system/internal/storage"
+	"time"
+)
+
+var ErrNotDisposable = errors.New("retention period has not ended")
+
+// DisposalFolder is a folder whose documents may all be destroyed. DisposalDate
+// is the latest disposal date among them.
+type DisposalFolder struct {
+	entity.Folder
+	DisposalDate  time.Time `json:"disposal_date"`
+	DocumentCount int64     `json:"document_count"`
+}
+
+// DisposalQueue lists what may be destroyed: documents past their retention
+// period and folders holding nothing else.
+type DisposalQueue struct {
+	Documents []entity.Document `json:"documents"`
+	Folders   []DisposalFolder  `json:"folders"`
+}
+
+// DisposalResult lists the IDs of what an approval deleted.
+type DisposalResult struct {
+	Documents []uint `json:"documents"`
+	Folders   []uint `json:"folders"`
+}
+
+// DisposalService runs the retention disposal queue. Approving a disposal
+// soft-deletes the items, so they remain in the database for the record.
+type DisposalService interface {
+	ListDisposalQueue(actor Actor, at time.Time) (*DisposalQueue, error)
+	ApproveDisposal(actor Actor, documentIDs, folderIDs []uint) (*DisposalResult, error)
+}
+
+type disposalService struct {
+	docRepo    repository.DocumentRepository
+	folderRepo repository.FolderRepository
+	transactor repository.Transactor
+	blobStore  storage.BlobStore
+}
+
+func NewDisposalService(docRepo repository.DocumentRepository, folderRepo repository.FolderRepository, transactor repository.Transactor, blobStore storage.BlobStore) DisposalService {
+	return &disposalService{docRepo: docRepo, folderRepo: folderRepo, transactor: transactor, blobStore: blobStore}
+}
+
+// ListDisposalQueue returns what may be destroyed by at; pass a future time to
+// see what is coming up.
+func (s *disposalService) ListDisposalQueue(actor Actor, at time.Time) (*DisposalQueue, error) {
+	documents, err := s.docRepo.ListDisposableDocuments(at)
+	if err != nil {
+		return nil, err
+	}
+	disposals, err := s.folderRepo.ListDisposableFolders(at)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := &DisposalQueue{Documents: documents, Folders: make([]DisposalFolder, 0, len(disposals))}
+	if queue.Documents == nil {
+		queue.Documents = []entity.Document{}
+	}
+	for _, disposal := range disposals {
+		folder, err := s.folderRepo.GetFolderByID(disposal.FolderID, actor.scope())
+		if err != nil {
+			return nil, err
+		}
+		queue.Folders = append(queue.Folders, DisposalFolder{
+			Folder:        *folder,
+			DisposalDate:  disposal.DisposalDate,
+			DocumentCount: disposal.DocumentCount,
+		})
+	}
+	return queue, nil
+}
+
+// ApproveDisposal deletes the given documents and folders, with the documents in
+// those folders. Every item must be due for disposal now; otherwise nothing is
+// deleted. Freed sheets go back to the folders the documents were filed in.
+func (s *disposalService) ApproveDisposal(actor Actor, documentIDs, folderIDs []uint) (*DisposalResult, error) {
+	// Listing an item twice must not free its sheets or file reference twice
+	documentIDs, folderIDs = uniqueIDs(documentIDs), uniqueIDs(folderIDs)
+
+	result := &DisposalResult{Documents: []uint{}, Folders: []uint{}}
+	// hash -> key of file contents no longer referenced after the disposal
+	unreferenced := make(map[string]string)
+
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		// Lock everything first so nothing is filed or changed while eligibility is checked
+		folders := make([]*entity.Folder, 0, len(folderIDs))
+		for _, id := range folderIDs {
+			folder, err := tx.LockFolderByID(id, actor.scopeFor(entity.PermissionManage))
+			if err != nil {
+				return ErrFolderNotFound
+			}
+			folders = append(folders, folder)
+		}
+		documents := make([]*entity.Document, 0, len(documentIDs))
+		for _, id := range documentIDs {
+			document, err := tx.LockDocumentByID(id, actor.scopeFor(entity.PermissionManage))
+			if err != nil {
+				return ErrDocumentNotFound
+			}
+			documents = append(documents, document)
+		}
+
+		now := time.Now()
+		dueFolders, err := tx.ListDisposableFolders(now)
+		if err != nil {
+			return err
+		}
+		dueDocuments, err := tx.ListDisposableDocuments(now)
+		if err != nil {
+			return err
+		}
+		folderDue := make(map[uint]bool, len(dueFolders))
+		for _, disposal := range dueFolders {
+			folderDue[disposal.FolderID] = true
+		}
+		documentDue := make(map[uint]bool, len(dueDocuments))
+		for _, document := range dueDocuments {
+			documentDue[document.ID] = true
+		}
+
+		disposedFolders := make(map[uint]bool, len(folders))
+		for _, folder := range folders {
+			if !folderDue[folder.ID] {
+				return fmt.Errorf("folder %d: %w", folder.ID, ErrNotDisposable)
+			}
+			if err := disposeFolder(tx, actor, folder, unreferenced); err != nil {
+				return err
+			}
+			disposedFolders[folder.ID] = true
+			result.Folders = append(result.Folders, folder.ID)
+		}
+
+		for _, document := range documents {
+			if !documentDue[document.ID] {
+				return fmt.Errorf("document %d: %w", document.ID, ErrNotDisposable)
+			}
+			// Already gone with its folder
+			if document.FolderID != nil && disposedFolders[*document.FolderID] {
+				continue
+			}
+			if err := disposeDocument(tx, actor, document, unreferenced); err != nil {
+				return err
+			}
+			result.Documents = append(result.Documents, document.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for hash, key := range unreferenced {
+		removeUnreferencedBlob(s.transactor, s.blobStore, hash, key)
+	}
+	return result, nil
+}
+
+// uniqueIDs drops repeated IDs, keeping the first occurrence of each.
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func disposeDocument(tx repository.Store, actor Actor, document *entity.Document, unreferenced map[string]string) error {
+	key, err := releaseFile(tx, document)
+	if err != nil {
+		return err
+	}
+	if key != "" {
+		unreferenced[document.FileHash] = key
+	}
+	if document.FolderID != nil {
+		if err := releaseSheets(tx, *document.FolderID, document.SheetsCount); err != nil {
+			return err
+		}
+	}
+	if err := tx.DeleteDocument(document.ID); err != nil {
+		return err
+	}
+	return recordAudit(tx, actor, entity.AuditActionDispose, entity.AuditEntityDocument, document.ID, document, nil)
+}
+
+func disposeFolder(tx repository.Store, actor Actor, folder *entity.Folder, unreferenced map[string]string) error {
+	documents, err := tx.ListDocumentsInFolder(folder.ID)
+	if err != nil {
+		return err
+	}
+	for i := range documents {
+		key, err := releaseFile(tx, &documents[i])
+		if err != nil {
+			return err
+		}
+		if key != "" {
+			unreferenced[documents[i].FileHash] = key
+		}
+		if err := recordAudit(tx, actor, entity.AuditActionDispose, entity.AuditEntityDocument, documents[i].ID, &documents[i], nil); err != nil {
+			return err
+		}
+	}
+	if err := tx.DeleteDocumentsInFolder(folder.ID); err != nil {
+		return err
+	}
+	if err := tx.DeleteFolder(folder.ID); err != nil {
+		return err
+	}
+	return recordAudit(tx, actor, entity.AuditActionDispose, entity.AuditEntityFolder, folder.ID, folder, nil)
+}
+
+// SetExpiry records when a document stops being in force; retention rules that
+// count from expiry start from this date. A nil expiresAt clears it.
+func (s *documentService) SetExpiry(actor Actor, id uint, expiresAt *time.Time) (*entity.Document, error) {
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		document, err := tx.LockDocumentByID(id, actor.scopeFor(entity.PermissionFile))
+		if err != nil {
+			return ErrDocumentNotFound
+		}
+		before := *document
+		document.ExpiresAt = expiresAt
+		if err := tx.UpdateDocument(document); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionUpdate, entity.AuditEntityDocument, id, &before, document)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetDocument(actor, id)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"errors"
+	"folder-system/internal/entity"
+	"folder-system/internal/label"
+	"folder-system/internal/repository"
+	"time"
+)
+
+var (
+	ErrScanSessionNotFound = errors.New("scan session not found")
+	ErrScanSessionClosed   = errors.New("scan session is closed")
+	ErrNoFolderScanned     = errors.New("scan a folder label before the documents to file into it")
+)
+
+// LookupResult is what a scanned label code points to. For a folder it holds the
+// documents filed in it and where it is kept; for a document, the document with
+// the location of its folder. Loan is set while the item is checked out.
+type LookupResult struct {
+	Kind      string            `json:"kind"`
+	Code      string            `json:"code"`
+	Folder    *entity.Folder    `json:"folder,omitempty"`
+	Documents []entity.Document `json:"documents,omitempty"`
+	Location  *Location         `json:"location,omitempty"`
+	Document  *entity.Document  `json:"document,omitempty"`
+	Loan      *entity.Loan      `json:"loan,omitempty"`
+}
+
+// ScanResult is the outcome of one scan in a session. Filed is false when the
+// scan selected a folder or the document already was in the current folder.
+type ScanResult struct {
+	Session  *entity.ScanSession `json:"session"`
+	Kind     string              `json:"kind"`
+	Folder   *entity.Folder      `json:"folder,omitempty"`
+	Document *entity.Document    `json:"document,omitempty"`
+	Filed    bool                `json:"filed"`
+}
+
+// ScanService serves barcode readers: it resolves scanned labels and runs scan
+// sessions that file documents into folders.
+type ScanService interface {
+	Lookup(actor Actor, code string) (*LookupResult, error)
+	StartScanSession(actor Actor) (*entity.ScanSession, error)
+	Scan(actor Actor, sessionID uint, code string, overrideCheckout bool) (*ScanResult, error)
+	CloseScanSession(actor Actor, sessionID uint) (*entity.ScanSession, error)
+}
+
+type scanService struct {
+	scanRepo        repository.ScanSessionRepository
+	docRepo         repository.DocumentRepository
+	loanRepo        repository.LoanRepository
+	transactor      repository.Transactor
+	folderService   FolderService
+	documentService DocumentService
+}
+
+func NewScanService(scanRepo repository.ScanSessionRepository, docRepo repository.DocumentRepository, loanRepo repository.LoanRepository, transactor repository.Transactor, folderService FolderService, documentService DocumentService) ScanService {
+	return &scanService{
+		scanRepo:        scanRepo,
+		docRepo:         docRepo,
+		loanRepo:        loanRepo,
+		transactor:      transactor,
+		folderService:   folderService,
+		documentService: documentService,
+	}
+}
+
+func (s *scanService) Lookup(actor Actor, code string) (*LookupResult, error) {
+	kind, id, err := label.ParseCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LookupResult{Kind: kind}
+	var loan *entity.Loan
+	if kind == label.KindFolder {
+		result.Code = label.FolderCode(id)
+		if result.Folder, err = s.folderService.GetFolder(actor, id); err != nil {
+			return nil, err
+		}
+		if result.Location, err = s.folderService.GetFolderLocation(actor, id); err != nil {
+			return nil, err
+		}
+		filter := repository.DocumentListFilter{FolderID: &id, SortBy: repository.DocumentSortID}
+		if result.Documents, err = s.docRepo.ListDocuments(filter, actor.scope()); err != nil {
+			return nil, err
+		}
+		loan, err = s.loanRepo.ActiveFolderLoan(id)
+	} else {
+		result.Code = label.DocumentCode(id)
+		if result.Document, err = s.documentService.GetDocument(actor, id); err != nil {
+			return nil, err
+		}
+		loan, err = s.loanRepo.ActiveDocumentLoan(id)
+	}
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	result.Loan = loan
+	return result, nil
+}
+
+func (s *scanService) StartScanSession(actor Actor) (*entity.ScanSession, error) {
+	session := &entity.ScanSession{CreatedBy: actor.UserID}
+	if err := s.scanRepo.CreateScanSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Scan handles one scanned code. A folder label makes that folder current; a
+// document label files the document into the current folder with the same
+// capacity, permission and check-out rules as moving it by hand. A failed scan
+// leaves the session as it was.
+func (s *scanService) Scan(actor Actor, sessionID uint, code string, overrideCheckout bool) (*ScanResult, error) {
+	kind, id, err := label.ParseCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ScanResult{Kind: kind}
+	err = s.transactor.Transaction(func(tx repository.Store) error {
+		session, err := tx.LockScanSession(sessionID, actor.UserID)
+		if err != nil {
+			return ErrScanSessionNotFound
+		}
+		if session.ClosedAt != nil {
+			return ErrScanSessionClosed
+		}
+		result.Session = session
+
+		if kind == label.KindFolder {
+			if result.Folder, err = tx.GetFolderByID(id, actor.scopeFor(entity.PermissionFile)); err != nil {
+				return ErrFolderNotFound
+			}
+			session.FolderID = &id
+			return tx.UpdateScanSession(session)
+		}
+
+		if session.FolderID == nil {
+			return ErrNoFolderScanned
+		}
+		document, err := tx.LockDocumentByID(id, actor.scopeFor(entity.PermissionFile))
+		if err != nil {
+			return ErrDocumentNotFound
+		}
+		result.Document = document
+		if sameFolder(document.FolderID, session.FolderID) {
+			return nil
+		}
+		if err := updateDocument(tx, actor, document, nil, nil, session.FolderID, nil, overrideCheckout); err != nil {
+			return err
+		}
+		result.Filed = true
+		session.FiledCount++
+		return tx.UpdateScanSession(session)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *scanService) CloseScanSession(actor Actor, sessionID uint) (*entity.ScanSession, error) {
+	var session *entity.ScanSession
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		var err error
+		session, err = tx.LockScanSession(sessionID, actor.UserID)
+		if err != nil {
+			return ErrScanSessionNotFound
+		}
+		if session.ClosedAt != nil {
+			return ErrScanSessionClosed
+		}
+		now := time.Now()
+		session.ClosedAt = &now
+		return tx.UpdateScanSession(session)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
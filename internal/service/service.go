@@ -1,8 +1,48 @@
 package service
 
+import (
+	"errors"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+)
+
+var ErrForbidden = errors.New("insufficient permissions")
+
+// Actor identifies the authenticated user a service call is made on behalf of.
+type Actor struct {
+	UserID uint
+	Role   string
+}
+
+func (a Actor) IsAdmin() bool {
+	return a.Role == entity.RoleAdmin
+}
+
+// scope lets admins see every record and everybody else their own records plus
+// those in folders shared with them for reading.
+func (a Actor) scope() repository.AccessScope {
+	return a.scopeFor(entity.PermissionRead)
+}
+
+// scopeFor is like scope but requires the given permission on shared folders.
+func (a Actor) scopeFor(permission string) repository.AccessScope {
+	return repository.AccessScope{UserID: a.UserID, Unrestricted: a.IsAdmin(), Permission: permission}
+}
+
 // Service holds all the service interfaces.
 type Service struct {
-	Auth     AuthService
-	Document DocumentService
-	Folder   FolderService
+	Auth       AuthService
+	Document   DocumentService
+	Folder     FolderService
+	Container  ContainerService
+	Assignment AssignmentService
+	Type       TypeService
+	Group      GroupService
+	Audit      AuditService
+	Upload     UploadService
+	Loan       LoanService
+	Label      LabelService
+	Scan       ScanService
+	Inventory  InventoryService
+	Disposal   DisposalService
 }
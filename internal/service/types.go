@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+)
+
+var (
+	ErrDocumentTypeNotFound = errors.New("document type not found")
+	ErrInvalidRetention     = errors.New("retention_months must not be negative and retention_trigger must be created or expiry")
+)
+
+type TypeService interface {
+	CreateDocumentType(name string) (*entity.DocumentType, error)
+	ListDocumentTypes() ([]entity.DocumentType, error)
+	SetRetention(actor Actor, documentTypeID uint, months int, trigger string) (*entity.DocumentType, error)
+	CreateFolderType(name string) (*entity.FolderType, error)
+	ListFolderTypes() ([]entity.FolderType, error)
+}
+
+type typeService struct {
+	typeRepo   repository.TypeRepository
+	transactor repository.Transactor
+}
+
+func NewTypeService(typeRepo repository.TypeRepository, transactor repository.Transactor) TypeService {
+	return &typeService{typeRepo: typeRepo, transactor: transactor}
+}
+
+func (s *typeService) CreateDocumentType(name string) (*entity.DocumentType, error) {
+	documentType := &entity.DocumentType{Name: name}
+	if err := s.typeRepo.CreateDocumentType(documentType); err != nil {
+		return nil, err
+	}
+	return documentType, nil
+}
+
+func (s *typeService) ListDocumentTypes() ([]entity.DocumentType, error) {
+	return s.typeRepo.ListDocumentTypes()
+}
+
+// SetRetention changes how long documents of the type are kept. A months value of
+// 0 keeps them forever. Disposal dates follow the new rule right away.
+func (s *typeService) SetRetention(actor Actor, documentTypeID uint, months int, trigger string) (*entity.DocumentType, error) {
+	if months < 0 || !entity.IsValidRetentionTrigger(trigger) {
+		return nil, ErrInvalidRetention
+	}
+
+	var documentType *entity.DocumentType
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		var err error
+		documentType, err = tx.GetDocumentType(documentTypeID)
+		if err != nil {
+			return ErrDocumentTypeNotFound
+		}
+		before := *documentType
+		documentType.RetentionMonths = months
+		documentType.RetentionTrigger = trigger
+		if err := tx.UpdateDocumentType(documentType); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, entity.AuditActionUpdate, entity.AuditEntityDocumentType, documentTypeID, &before, documentType)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return documentType, nil
+}
+
+func (s *typeService) CreateFolderType(name string) (*entity.FolderType, error) {
+	folderType := &entity.FolderType{Name: name}
+	if err := s.typeRepo.CreateFolderType(folderType); err != nil {
+		return nil, err
+	}
+	return folderType, nil
+}
+
+func (s *typeService) ListFolderTypes() ([]entity.FolderType, error) {
+	return s.typeRepo.ListFolderTypes()
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"errors"
+	"folder-system/internal/config"
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+	"folder-system/internal/utils"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	ErrUploadNotFound    = errors.New("upload not found")
+	ErrUploadOffset      = errors.New("upload offset does not match")
+	ErrUploadTooLarge    = errors.New("chunk exceeds declared upload length")
+	ErrUploadIncomplete  = errors.New("upload is not complete yet")
+	ErrUploadCompleted   = errors.New("upload is already completed")
+	ErrInvalidUploadSize = errors.New("upload length must be positive")
+)
+
+// UploadService implements resumable uploads: a client declares the total length,
+// sends the content in chunks at explicit offsets and may resume after a failure by
+// asking for the current offset. The finished file is attached to the document.
+type UploadService interface {
+	CreateUpload(actor Actor, documentID uint, length int64, fileName, mimeType string) (*entity.Upload, error)
+	GetUpload(actor Actor, id string) (*entity.Upload, error)
+	AppendChunk(actor Actor, id string, offset int64, r io.Reader) (*entity.Upload, error)
+	FinalizeUpload(actor Actor, id string) (*entity.Document, error)
+	AbortUpload(actor Actor, id string) error
+	CleanupExpired() (int, error)
+}
+
+type uploadService struct {
+	uploadRepo      repository.UploadRepository
+	docRepo         repository.DocumentRepository
+	transactor      repository.Transactor
+	documentService DocumentService
+	dir             string
+	ttl             time.Duration
+}
+
+func NewUploadService(uploadRepo repository.UploadRepository, docRepo repository.DocumentRepository, transactor repository.Transactor, documentService DocumentService, cfg config.StorageConfig) (UploadService, error) {
+	if err := os.MkdirAll(cfg.UploadDir, 0o755); err != nil {
+		return nil, err
+	}
+	return &uploadService{
+		uploadRepo:      uploadRepo,
+		docRepo:         docRepo,
+		transactor:      transactor,
+		documentService: documentService,
+		dir:             cfg.UploadDir,
+		ttl:             time.Duration(cfg.UploadTTL) * time.Hour,
+	}, nil
+}
+
+func (s *uploadService) CreateUpload(actor Actor, documentID uint, length int64, fileName, mimeType string) (*entity.Upload, error) {
+	if length <= 0 {
+		return nil, ErrInvalidUploadSize
+	}
+	if _, err := s.docRepo.GetDocumentByID(documentID, actor.scopeFor(entity.PermissionFile)); err != nil {
+		return nil, ErrDocumentNotFound
+	}
+
+	id, err := utils.NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+	upload := &entity.Upload{
+		ID:         id,
+		DocumentID: documentID,
+		CreatedBy:  actor.UserID,
+		Length:     length,
+		FileName:   fileName,
+		MIMEType:   mimeType,
+		ExpiresAt:  time.Now().Add(s.ttl),
+	}
+
+	f, err := os.Create(s.partPath(id))
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := s.uploadRepo.CreateUpload(upload); err != nil {
+		_ = os.Remove(s.partPath(id))
+		return nil, err
+	}
+	return upload, nil
+}
+
+func (s *uploadService) GetUpload(actor Actor, id string) (*entity.Upload, error) {
+	upload, err := s.uploadRepo.GetUpload(id, actor.UserID)
+	if err != nil {
+		return nil, ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+// AppendChunk writes the content of r at offset, which must equal the number of
+// bytes received so far. The chunk is first read into a spool file without holding
+// any lock, so a slow client does not block others; the upload row is then locked
+// only while the spooled chunk is appended, so concurrent requests for the same
+// upload are applied one after another and all but the first fail the offset check.
+// The upload is finalized automatically once the last byte has arrived.
+func (s *uploadService) AppendChunk(actor Actor, id string, offset int64, r io.Reader) (*entity.Upload, error) {
+	upload, err := s.uploadRepo.GetUpload(id, actor.UserID)
+	if err != nil {
+		return nil, ErrUploadNotFound
+	}
+	if upload.CompletedAt != nil {
+		return nil, ErrUploadCompleted
+	}
+	if offset != upload.Offset {
+		return nil, ErrUploadOffset
+	}
+
+	chunk, err := s.spoolChunk(id, r, upload.Length-offset)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(chunk.Name())
+	defer chunk.Close()
+
+	err = s.transactor.Transaction(func(tx repository.Store) error {
+		var err error
+		upload, err = tx.LockUpload(id, actor.UserID)
+		if err != nil {
+			return ErrUploadNotFound
+		}
+		if upload.CompletedAt != nil {
+			return ErrUploadCompleted
+		}
+		if offset != upload.Offset {
+			return ErrUploadOffset
+		}
+
+		written, err := s.writeChunk(upload, chunk)
+		if err != nil {
+			return err
+		}
+		upload.Offset += written
+		// Every chunk keeps an active upload alive for another TTL
+		upload.ExpiresAt = time.Now().Add(s.ttl)
+		return tx.UpdateUpload(upload)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.Offset == upload.Length {
+		// A concurrent finalize that got there first is just as good
+		if _, err := s.FinalizeUpload(actor, id); err != nil && !errors.Is(err, ErrUploadCompleted) {
+			return nil, err
+		}
+		return s.GetUpload(actor, id)
+	}
+	return upload, nil
+}
+
+// spoolChunk reads a chunk of at most remaining bytes into a temporary file next
+// to the partial file and returns it rewound. The caller closes and removes it.
+func (s *uploadService) spoolChunk(id string, r io.Reader, remaining int64) (*os.File, error) {
+	f, err := os.CreateTemp(s.dir, id+".chunk-*")
+	if err != nil {
+		return nil, err
+	}
+
+	// Read one byte past the remaining length to notice oversized chunks
+	n, err := io.Copy(f, io.LimitReader(r, remaining+1))
+	if err == nil && n > remaining {
+		err = ErrUploadTooLarge
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
+
+// writeChunk appends r to the partial file of the upload. A failed or oversized
+// write is truncated away so the file always matches the recorded offset.
+func (s *uploadService) writeChunk(upload *entity.Upload, r io.Reader) (int64, error) {
+	f, err := os.OpenFile(s.partPath(upload.ID), os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if err := f.Truncate(upload.Offset); err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(upload.Offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	// Read one byte past the remaining length to notice oversized chunks
+	remaining := upload.Length - upload.Offset
+	written, err := io.Copy(f, io.LimitReader(r, remaining+1))
+	if err == nil && written > remaining {
+		err = ErrUploadTooLarge
+	}
+	if err != nil {
+		_ = f.Truncate(upload.Offset)
+		return 0, err
+	}
+	return written, f.Sync()
+}
+
+// FinalizeUpload attaches the assembled file to the document and removes the
+// partial file. The upload is marked completed in the attaching transaction under
+// its row lock, so a retry or a concurrent finalize cannot attach the file twice.
+// It can be retried if attaching failed after the last chunk.
+func (s *uploadService) FinalizeUpload(actor Actor, id string) (*entity.Document, error) {
+	upload, err := s.uploadRepo.GetUpload(id, actor.UserID)
+	if err != nil {
+		return nil, ErrUploadNotFound
+	}
+	if upload.CompletedAt != nil {
+		return nil, ErrUploadCompleted
+	}
+	if upload.Offset != upload.Length {
+		return nil, ErrUploadIncomplete
+	}
+
+	f, err := os.Open(s.partPath(id))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	complete := func(tx repository.Store) error {
+		upload, err := tx.LockUpload(id, actor.UserID)
+		if err != nil {
+			return ErrUploadNotFound
+		}
+		if upload.CompletedAt != nil {
+			return ErrUploadCompleted
+		}
+		now := time.Now()
+		upload.CompletedAt = &now
+		return tx.UpdateUpload(upload)
+	}
+	document, err := s.documentService.AttachFileWith(actor, upload.DocumentID, upload.FileName, upload.MIMEType, f, complete)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = os.Remove(s.partPath(id))
+	return document, nil
+}
+
+func (s *uploadService) AbortUpload(actor Actor, id string) error {
+	if _, err := s.uploadRepo.GetUpload(id, actor.UserID); err != nil {
+		return ErrUploadNotFound
+	}
+	if err := s.uploadRepo.DeleteUpload(id); err != nil {
+		return err
+	}
+	_ = os.Remove(s.partPath(id))
+	return nil
+}
+
+// CleanupExpired deletes unfinished uploads past their expiry time together with
+// their partial files and returns how many were removed.
+func (s *uploadService) CleanupExpired() (int, error) {
+	uploads, err := s.uploadRepo.ListExpiredUploads(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, upload := range uploads {
+		if err := s.uploadRepo.DeleteUpload(upload.ID); err != nil {
+			return 0, err
+		}
+		_ = os.Remove(s.partPath(upload.ID))
+	}
+	return len(uploads), nil
+}
+
+func (s *uploadService) partPath(id string) string {
+	return filepath.Join(s.dir, id+".part")
+}
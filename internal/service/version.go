@@ -0,0 +1,112 @@
+package service
+
+import (
+	"folder-system/internal/entity"
+	"folder-system/internal/repository"
+)
+
+func (s *documentService) ListVersions(actor Actor, id uint) ([]entity.DocumentVersion, error) {
+	if _, err := s.docRepo.GetDocumentByID(id, actor.scope()); err != nil {
+		return nil, ErrDocumentNotFound
+	}
+	return s.versionRepo.ListDocumentVersions(id)
+}
+
+// RestoreVersion brings title, sheet count and folder of a document back to an older
+// version. It goes through the regular update path, so folder capacity is checked
+// again and the restore itself becomes a new version.
+func (s *documentService) RestoreVersion(actor Actor, id uint, version int, overrideCheckout bool) (*entity.Document, error) {
+	err := s.transactor.Transaction(func(tx repository.Store) error {
+		document, err := tx.LockDocumentByID(id, actor.scopeFor(entity.PermissionFile))
+		if err != nil {
+			return ErrDocumentNotFound
+		}
+
+		target, err := tx.GetDocumentVersion(id, version)
+		if err != nil {
+			return ErrVersionNotFound
+		}
+
+		// A version filed nowhere restores to "no folder"
+		var unfiled uint
+		folderID := target.FolderID
+		if folderID == nil {
+			folderID = &unfiled
+		}
+
+		return updateDocument(tx, actor, document, &target.Title, &target.SheetsCount, folderID, &target.Version, overrideCheckout)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.docRepo.GetDocumentByID(id, actor.scope())
+}
+
+// recordVersion appends a version with the document's current state. Documents
+// created before versioning existed get their previous state recorded first, so
+// that it can be restored too.
+func recordVersion(tx repository.Store, actor Actor, before, after *entity.Document, restoredFrom *int) error {
+	latest, err := tx.LatestDocumentVersion(after.ID)
+	if err != nil {
+		return err
+	}
+
+	if latest == 0 && before != nil {
+		baseline, err := newDocumentVersion(before, 1, before.CreatedBy, versionChangedFields(nil, before))
+		if err != nil {
+			return err
+		}
+		if err := tx.CreateDocumentVersion(baseline); err != nil {
+			return err
+		}
+		latest = 1
+	}
+
+	version, err := newDocumentVersion(after, latest+1, actor.UserID, versionChangedFields(before, after))
+	if err != nil {
+		return err
+	}
+	version.RestoredFrom = restoredFrom
+	return tx.CreateDocumentVersion(version)
+}
+
+func newDocumentVersion(document *entity.Document, number int, changedBy uint, changedFields []string) (*entity.DocumentVersion, error) {
+	fields, err := entity.NewJSON(changedFields)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.DocumentVersion{
+		DocumentID:     document.ID,
+		Version:        number,
+		Title:          document.Title,
+		SheetsCount:    document.SheetsCount,
+		FolderID:       document.FolderID,
+		DocumentTypeID: document.DocumentTypeID,
+		ChangedBy:      changedBy,
+		ChangedFields:  fields,
+	}, nil
+}
+
+// versionChangedFields lists the versioned fields that differ between two states.
+// With no previous state every field counts as changed.
+func versionChangedFields(before, after *entity.Document) []string {
+	if before == nil {
+		return []string{"title", "sheets_count", "folder_id", "document_type_id"}
+	}
+
+	changed := []string{}
+	if before.Title != after.Title {
+		changed = append(changed, "title")
+	}
+	if before.SheetsCount != after.SheetsCount {
+		changed = append(changed, "sheets_count")
+	}
+	if !sameFolder(before.FolderID, after.FolderID) {
+		changed = append(changed, "folder_id")
+	}
+	if before.DocumentTypeID != after.DocumentTypeID {
+		changed = append(changed, "document_type_id")
+	}
+	return changed
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"folder-system/internal/config"
+)
+
+func testS3Config(endpoint string) config.S3Config {
+	return config.S3Config{
+		Endpoint:  endpoint,
+		Region:    "eu-central-1",
+		Bucket:    "archive",
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		PathStyle: true,
+	}
+}
+
+// expectedSignature signs a canonical request spelled out by hand, following the
+// SigV4 documentation, so the test does not share code with S3Store.sign.
+func expectedSignature(secret, amzDate, region, canonicalRequest string) string {
+	mac := func(key []byte, data string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(data))
+		return h.Sum(nil)
+	}
+	date := amzDate[:8]
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" +
+		date + "/" + region + "/s3/aws4_request\n" + hex.EncodeToString(sum[:])
+	key := mac(mac(mac(mac([]byte("AWS4"+secret), date), region), "s3"), "aws4_request")
+	return hex.EncodeToString(mac(key, stringToSign))
+}
+
+func TestS3Sign(t *testing.T) {
+	cfg := testS3Config("http://minio.local:9000")
+	store := NewS3Store(cfg)
+	req, err := http.NewRequest(http.MethodPut, "http://minio.local:9000/archive/ab/cd%20ef.pdf", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.sign(req, time.Date(2024, 5, 17, 9, 30, 0, 0, time.UTC))
+
+	canonicalRequest := "PUT\n" +
+		"/archive/ab/cd%20ef.pdf\n" +
+		"\n" +
+		"host:minio.local:9000\n" +
+		"x-amz-content-sha256:UNSIGNED-PAYLOAD\n" +
+		"x-amz-date:20240517T093000Z\n" +
+		"\n" +
+		"host;x-amz-content-sha256;x-amz-date\n" +
+		"UNSIGNED-PAYLOAD"
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240517/eu-central-1/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, " +
+		"Signature=" + expectedSignature(cfg.SecretKey, "20240517T093000Z", cfg.Region, canonicalRequest)
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("x-amz-date"); got != "20240517T093000Z" {
+		t.Errorf("x-amz-date = %q", got)
+	}
+	if got := req.Header.Get("x-amz-content-sha256"); got != "UNSIGNED-PAYLOAD" {
+		t.Errorf("x-amz-content-sha256 = %q", got)
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	tests := map[string]string{
+		"ab/cdef":           "ab/cdef",
+		"a b/c+d":           "a%20b/c%2Bd",
+		"x~y_z-1.pdf":       "x~y_z-1.pdf",
+		"отчет.pdf":         "%D0%BE%D1%82%D1%87%D0%B5%D1%82.pdf",
+		"percent%20already": "percent%2520already",
+	}
+	for key, want := range tests {
+		if got := escapePath(key); got != want {
+			t.Errorf("escapePath(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+// TestS3Requests checks the requests each operation sends to the store.
+func TestS3Requests(t *testing.T) {
+	type seen struct {
+		method, path, contentType, body string
+		contentLength                   int64
+		header                          http.Header
+	}
+	var requests []seen
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, seen{
+			method:        r.Method,
+			path:          r.URL.EscapedPath(),
+			contentType:   r.Header.Get("Content-Type"),
+			body:          string(body),
+			contentLength: r.ContentLength,
+			header:        r.Header.Clone(),
+		})
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/missing"):
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete && strings.HasSuffix(r.URL.Path, "/missing"):
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet:
+			_, _ = io.WriteString(w, "scan contents")
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	store := NewS3Store(testS3Config(server.URL))
+	if err := store.Put("ab/cd ef.pdf", strings.NewReader("scan contents"), 13, "application/pdf"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	rc, err := store.Get("ab/cd ef.pdf")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "scan contents" {
+		t.Errorf("get = %q, want %q", data, "scan contents")
+	}
+	if _, err := store.Get("missing"); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("get missing: err = %v, want ErrBlobNotFound", err)
+	}
+	if err := store.Delete("ab/cd ef.pdf"); err != nil {
+		t.Errorf("delete: %v", err)
+	}
+	if err := store.Delete("missing"); err != nil {
+		t.Errorf("delete missing: %v", err)
+	}
+
+	want := []struct{ method, path string }{
+		{http.MethodPut, "/archive/ab/cd%20ef.pdf"},
+		{http.MethodGet, "/archive/ab/cd%20ef.pdf"},
+		{http.MethodGet, "/archive/missing"},
+		{http.MethodDelete, "/archive/ab/cd%20ef.pdf"},
+		{http.MethodDelete, "/archive/missing"},
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(requests), len(want))
+	}
+	host := strings.TrimPrefix(server.URL, "http://")
+	for i, w := range want {
+		got := requests[i]
+		if got.method != w.method || got.path != w.path {
+			t.Errorf("request %d = %s %s, want %s %s", i, got.method, got.path, w.method, w.path)
+		}
+		auth := got.header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+			!strings.Contains(auth, "/eu-central-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+			t.Errorf("request %d: Authorization = %q", i, auth)
+		}
+
+		// The signature must match the request as the server received it
+		date := got.header.Get("x-amz-date")
+		canonicalRequest := got.method + "\n" + got.path + "\n\n" +
+			"host:" + host + "\n" +
+			"x-amz-content-sha256:UNSIGNED-PAYLOAD\n" +
+			"x-amz-date:" + date + "\n\n" +
+			"host;x-amz-content-sha256;x-amz-date\nUNSIGNED-PAYLOAD"
+		signature := expectedSignature("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", date, "eu-central-1", canonicalRequest)
+		if !strings.HasSuffix(auth, "Signature="+signature) {
+			t.Errorf("request %d: signature does not match the received request", i)
+		}
+	}
+
+	put := requests[0]
+	if put.contentType != "application/pdf" || put.contentLength != 13 || put.body != "scan contents" {
+		t.Errorf("put sent Content-Type %q, Content-Length %d, body %q", put.contentType, put.contentLength, put.body)
+	}
+}
+
+func TestS3VirtualHostedRequest(t *testing.T) {
+	cfg := testS3Config("https://s3.eu-central-1.amazonaws.com")
+	cfg.PathStyle = false
+	req, err := NewS3Store(cfg).newRequest(http.MethodGet, "ab/cd ef.pdf", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := url.Parse("https://archive.s3.eu-central-1.amazonaws.com/ab/cd%20ef.pdf")
+	if req.URL.String() != want.String() {
+		t.Errorf("url = %s, want %s", req.URL, want)
+	}
+}
+
+func TestS3StalledStore(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	store := NewS3Store(testS3Config(server.URL))
+	store.client = newS3Client(50 * time.Millisecond)
+	if _, err := store.Get("slow"); err == nil {
+		t.Fatal("get from a stalled store succeeded")
+	}
+}
+
+// A download that takes longer than the timeout in total but keeps delivering
+// data must not be cut off.
+func TestS3SlowTransfer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 8; i++ {
+			_, _ = io.WriteString(w, "chunk")
+			w.(http.Flusher).Flush()
+			time.Sleep(30 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	store := NewS3Store(testS3Config(server.URL))
+	store.client = newS3Client(100 * time.Millisecond)
+	rc, err := store.Get("slow")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if want := strings.Repeat("chunk", 8); string(data) != want {
+		t.Errorf("body = %q, want %q", data, want)
+	}
+}